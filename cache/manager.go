@@ -3,6 +3,7 @@ package cache
 import (
 	"sync"
 
+	"github.com/lazygo/lazygo/health"
 	"github.com/lazygo/lazygo/internal"
 )
 
@@ -44,7 +45,12 @@ func (m *Manager) init(conf []Config, defaultName string) error {
 		if err != nil {
 			return err
 		}
+		cache = withMetrics(item.Name, cache)
 		m.Store(item.Name, cache)
+		// 自定义适配器实现 health.Pinger 时注册检查，内置适配器使用的redis、memory、memcache实例由对应组件注册
+		if p, ok := cache.(health.Pinger); ok {
+			health.Register("cache/"+item.Name, p.Ping)
+		}
 		if defaultName == item.Name {
 			m.defaultName = defaultName
 		}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/lazygo/lazygo/health"
+	"github.com/lazygo/lazygo/metrics"
+)
+
+var cacheRequests = metrics.NewCounterVec("lazygo_cache_requests_total",
+	"Total number of cache lookups by result.", "cache", "result")
+
+// instrumented 记录缓存命中和未命中次数
+type instrumented struct {
+	Cache
+	hit  *metrics.Counter
+	miss *metrics.Counter
+}
+
+// instrumentedPinger 被包装的缓存实现 health.Pinger 时转发 Ping
+type instrumentedPinger struct {
+	*instrumented
+	pinger health.Pinger
+}
+
+func (c *instrumentedPinger) Ping(ctx context.Context) error {
+	return c.pinger.Ping(ctx)
+}
+
+func withMetrics(name string, c Cache) Cache {
+	ic := &instrumented{
+		Cache: c,
+		hit:   cacheRequests.With(name, "hit"),
+		miss:  cacheRequests.With(name, "miss"),
+	}
+	if p, ok := c.(health.Pinger); ok {
+		return &instrumentedPinger{instrumented: ic, pinger: p}
+	}
+	return ic
+}
+
+func (c *instrumented) observe(hit bool, err error) {
+	if err != nil {
+		return
+	}
+	if hit {
+		c.hit.Inc()
+	} else {
+		c.miss.Inc()
+	}
+}
+
+func (c *instrumented) Remember(key string, value func() (any, error), ttl int64, ret any) (bool, error) {
+	hit, err := c.Cache.Remember(key, value, ttl, ret)
+	c.observe(hit, err)
+	return hit, err
+}
+
+func (c *instrumented) Get(key string, ret any) (bool, error) {
+	hit, err := c.Cache.Get(key, ret)
+	c.observe(hit, err)
+	return hit, err
+}
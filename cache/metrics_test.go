@@ -0,0 +1,33 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lazygo/lazygo/health"
+)
+
+type pingCache struct {
+	Cache
+	pings int
+}
+
+func (c *pingCache) Ping(ctx context.Context) error {
+	c.pings++
+	return nil
+}
+
+func TestMetricsForwardPing(t *testing.T) {
+	if _, ok := withMetrics("lru", &lruCache{}).(health.Pinger); ok {
+		t.Error("withMetrics() should not add Ping to a cache without it")
+	}
+
+	pc := &pingCache{Cache: &lruCache{}}
+	p, ok := withMetrics("ping", pc).(health.Pinger)
+	if !ok {
+		t.Fatal("withMetrics() hides health.Pinger")
+	}
+	if err := p.Ping(context.Background()); err != nil || pc.pings != 1 {
+		t.Errorf("Ping() = %v, pings = %d", err, pc.pings)
+	}
+}
@@ -92,7 +92,7 @@ func (r *redisCache) Get(key string, ret any) (bool, error) {
 		return false, err
 	}
 
-	return true, nil
+	return false, nil
 }
 
 func (r *redisCache) Has(key string) (bool, error) {
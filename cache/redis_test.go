@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"testing"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// fakeRedis 只支持 GET 的redis服务端，data 中不存在的key返回nil，值为 -ERR 开头时返回错误
+func fakeRedis(data map[string]string) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		client, server := net.Pipe()
+		go func() {
+			defer server.Close()
+			r := bufio.NewReader(server)
+			for {
+				args, err := readCommand(r)
+				if err != nil {
+					return
+				}
+				reply := "+OK\r\n"
+				switch {
+				case strings.EqualFold(args[0], "HELLO"):
+					reply = "-ERR unknown command 'HELLO'\r\n"
+				case strings.EqualFold(args[0], "GET") && len(args) == 2:
+					reply = "$-1\r\n"
+					if v, ok := data[args[1]]; ok && strings.HasPrefix(v, "-ERR") {
+						reply = v + "\r\n"
+					} else if ok {
+						reply = fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+					}
+				}
+				if _, err := server.Write([]byte(reply)); err != nil {
+					return
+				}
+			}
+		}()
+		return client, nil
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	var n int
+	if _, err := fmt.Fscanf(r, "*%d\r\n", &n); err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		var l int
+		if _, err := fmt.Fscanf(r, "$%d\r\n", &l); err != nil {
+			return nil, err
+		}
+		b := make([]byte, l+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		args[i] = string(b[:l])
+	}
+	return args, nil
+}
+
+func TestRedisGet(t *testing.T) {
+	c := &redisCache{
+		prefix: "p:",
+		handler: goredis.NewClient(&goredis.Options{
+			Protocol:        2,
+			DisableIdentity: true,
+			Dialer:          fakeRedis(map[string]string{"p:hit": `{"a":1}`, "p:broken": "-ERR broken"}),
+		}),
+	}
+	defer c.handler.Close()
+
+	var ret map[string]int
+	ok, err := c.Get("hit", &ret)
+	if err != nil || !ok || ret["a"] != 1 {
+		t.Fatalf("hit: ok = %v, ret = %v, err = %v", ok, ret, err)
+	}
+	// 未命中时返回false且不修改ret，与其他适配器一致
+	ok, err = c.Get("miss", &ret)
+	if err != nil || ok || ret["a"] != 1 {
+		t.Fatalf("miss: ok = %v, ret = %v, err = %v", ok, ret, err)
+	}
+	ok, err = c.Get("broken", &ret)
+	if err == nil || ok {
+		t.Fatalf("error: ok = %v, err = %v", ok, err)
+	}
+}
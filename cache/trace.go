@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/lazygo/lazygo/trace"
+)
+
+// traced 为每次缓存操作创建子span
+type traced struct {
+	Cache
+	ctx context.Context
+}
+
+// WithContext 返回在ctx的链路中为每次操作创建子span的缓存
+// 如 cache.WithContext(ctx, instance).Get(key, &ret)
+func WithContext(ctx context.Context, c Cache) Cache {
+	return &traced{Cache: c, ctx: ctx}
+}
+
+func (c *traced) start(op, key string) *trace.Span {
+	_, span := trace.StartChild(c.ctx, trace.KindClient, "cache "+op)
+	return span.SetAttr("cache.key", key)
+}
+
+func (c *traced) Remember(key string, value func() (any, error), ttl int64, ret any) (bool, error) {
+	span := c.start("remember", key)
+	defer span.End()
+	hit, err := c.Cache.Remember(key, value, ttl, ret)
+	span.SetAttr("cache.hit", hit).SetError(err)
+	return hit, err
+}
+
+func (c *traced) Get(key string, ret any) (bool, error) {
+	span := c.start("get", key)
+	defer span.End()
+	hit, err := c.Cache.Get(key, ret)
+	span.SetAttr("cache.hit", hit).SetError(err)
+	return hit, err
+}
+
+func (c *traced) Set(key string, value any, ttl int64) error {
+	span := c.start("set", key)
+	defer span.End()
+	err := c.Cache.Set(key, value, ttl)
+	span.SetError(err)
+	return err
+}
+
+func (c *traced) Has(key string) (bool, error) {
+	span := c.start("has", key)
+	defer span.End()
+	ok, err := c.Cache.Has(key)
+	span.SetError(err)
+	return ok, err
+}
+
+func (c *traced) HasMulti(keys ...string) (map[string]bool, error) {
+	_, span := trace.StartChild(c.ctx, trace.KindClient, "cache has_multi")
+	defer span.End()
+	span.SetAttr("cache.keys", len(keys))
+	ret, err := c.Cache.HasMulti(keys...)
+	span.SetError(err)
+	return ret, err
+}
+
+func (c *traced) Forget(key string) error {
+	span := c.start("forget", key)
+	defer span.End()
+	err := c.Cache.Forget(key)
+	span.SetError(err)
+	return err
+}
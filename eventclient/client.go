@@ -0,0 +1,441 @@
+package eventclient
+
+import (
+	stdContext "context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	mrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/lazygo/lazygo/server"
+	"github.com/lazygo/pkg/waiter"
+)
+
+var (
+	ErrNotConnected = errors.New("event client not connected")
+	ErrDisconnected = errors.New("event client disconnected")
+	ErrClosed       = errors.New("event client closed")
+)
+
+const (
+	defaultReconnectMin = 500 * time.Millisecond
+	defaultReconnectMax = 30 * time.Second
+	defaultWriteTimeout = 10 * time.Second
+)
+
+// Config 客户端配置
+type Config struct {
+	// URL WebSocket路由地址，如 ws://127.0.0.1:8080/api/connection
+	URL string
+	// Header 握手时发送的请求头，如 Authorization
+	Header http.Header
+	// Subprotocol 消息编码，可选 server.SubprotocolJSON、server.SubprotocolMsgpack、server.SubprotocolCBOR，默认json
+	Subprotocol string
+	// ReconnectMin 断线重连的最短等待时间，默认500毫秒，之后每次翻倍
+	ReconnectMin time.Duration
+	// ReconnectMax 断线重连的最长等待时间，默认30秒
+	ReconnectMax time.Duration
+	// LastEventID 首次连接时携带的最后收到的广播消息id，之后由客户端自动记录
+	LastEventID uint64
+	// WriteTimeout 单条消息的写入超时时间，默认10秒
+	WriteTimeout time.Duration
+	// OnConnect 连接（包括重连）成功后调用，可用于重新加入房间
+	OnConnect func(c *Client)
+	// OnMessage 处理服务端推送的消息（rid为0），如广播，按接收顺序在单独的goroutine中逐条调用，
+	// 处理较慢时消息在队列中等待，不影响请求的响应
+	OnMessage func(data *server.EventData)
+	// OnRequest 处理服务端发起的请求，返回的数据作为响应发送，为nil时返回空响应
+	OnRequest func(ctx stdContext.Context, req *server.EventData) *server.EventData
+}
+
+// Client Event协议客户端，连接断开后自动重连
+type Client struct {
+	conf   Config
+	codec  server.EventCodec
+	waiter *waiter.Waiter[*server.EventData]
+	rid    atomic.Uint64
+	// lastID 最后收到的广播消息id，重连时通过 Last-Event-ID 请求头补发错过的消息
+	lastID atomic.Uint64
+
+	mu   sync.RWMutex
+	conn *websocket.Conn
+	lost chan struct{}
+	// sent 当前连接上已发出、还未收到响应的请求rid，包括已超时的请求
+	sent map[uint64]struct{}
+	// answered 当前连接上已回复、还未收到服务端确认的服务端请求rid
+	answered map[uint64]struct{}
+
+	// messages 等待 OnMessage 处理的推送消息，ready 在队列非空时通知处理goroutine
+	msgMu    sync.Mutex
+	messages []*server.EventData
+	ready    chan struct{}
+	drained  chan struct{}
+
+	ctx    stdContext.Context
+	cancel stdContext.CancelFunc
+	done   chan struct{}
+}
+
+// Dial 连接服务端，首次连接失败时返回错误，之后断线会自动重连
+func Dial(ctx stdContext.Context, conf Config) (*Client, error) {
+	if conf.ReconnectMin <= 0 {
+		conf.ReconnectMin = defaultReconnectMin
+	}
+	if conf.ReconnectMax < conf.ReconnectMin {
+		conf.ReconnectMax = max(defaultReconnectMax, conf.ReconnectMin)
+	}
+	if conf.WriteTimeout <= 0 {
+		conf.WriteTimeout = defaultWriteTimeout
+	}
+	c := &Client{
+		conf:    conf,
+		waiter:  waiter.NewWaiter[*server.EventData](),
+		done:    make(chan struct{}),
+		ready:   make(chan struct{}, 1),
+		drained: make(chan struct{}),
+	}
+	// rid 从随机值开始，避免与服务端发起请求的rid冲突
+	var seed [8]byte
+	_, _ = rand.Read(seed[:])
+	c.rid.Store(binary.BigEndian.Uint64(seed[:]) >> 12)
+	c.lastID.Store(conf.LastEventID)
+
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.ctx, c.cancel = stdContext.WithCancel(stdContext.Background())
+	c.setConn(conn)
+	go c.deliver()
+	go c.run(conn)
+	return c, nil
+}
+
+// Request 发送请求并等待响应
+// method 为路由的请求方法，通过 X-HTTP-Method-Override 请求头发送，为空时按连接的Event方法路由，如 server.MethodWebSocket；
+// body 为 []byte 或 json.RawMessage 时按原样发送，其他类型编码为json
+func (c *Client) Request(ctx stdContext.Context, method, uri string, body any) (*server.EventData, error) {
+	data := &server.EventData{URI: uri, Header: http.Header{}}
+	if method != "" {
+		data.Header.Set(server.HeaderXHTTPMethodOverride, method)
+	}
+	switch b := body.(type) {
+	case nil:
+	case []byte:
+		data.Body = b
+	case json.RawMessage:
+		data.Body = b
+	default:
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		data.Body = raw
+		data.Header.Set(server.HeaderContentType, server.MIMEApplicationJSON)
+	}
+	return c.Do(ctx, data)
+}
+
+// Do 发送请求并等待响应，rid为0时自动分配
+func (c *Client) Do(ctx stdContext.Context, data *server.EventData) (*server.EventData, error) {
+	if data.RID == 0 {
+		data.RID = c.nextRID()
+	}
+	conn, lost, err := c.current()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := stdContext.WithCancel(ctx)
+	defer cancel()
+	wait, stop := c.waiter.Get(ctx, strconv.FormatUint(data.RID, 10))
+	c.mark(conn, &c.sent, data.RID)
+	if err := c.write(ctx, conn, data); err != nil {
+		stop()
+		return nil, err
+	}
+	go func() {
+		// 连接断开时不再等待响应
+		select {
+		case <-lost:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	resp, err := wait()
+	if err != nil {
+		select {
+		case <-lost:
+			return nil, ErrDisconnected
+		default:
+		}
+		return nil, err
+	}
+	return resp, nil
+}
+
+// Send 发送不需要响应的消息
+func (c *Client) Send(ctx stdContext.Context, data *server.EventData) error {
+	conn, _, err := c.current()
+	if err != nil {
+		return err
+	}
+	return c.write(ctx, conn, data)
+}
+
+// LastEventID 最后收到的广播消息id
+func (c *Client) LastEventID() uint64 {
+	return c.lastID.Load()
+}
+
+// Connected 当前是否已连接
+func (c *Client) Connected() bool {
+	_, _, err := c.current()
+	return err == nil
+}
+
+// Close 关闭连接并停止重连
+func (c *Client) Close() error {
+	c.cancel()
+	<-c.done
+	<-c.drained
+	return nil
+}
+
+func (c *Client) nextRID() uint64 {
+	rid := c.rid.Add(1)
+	if rid == 0 {
+		rid = c.rid.Add(1)
+	}
+	return rid
+}
+
+func (c *Client) dial(ctx stdContext.Context) (*websocket.Conn, error) {
+	header := c.conf.Header.Clone()
+	if id := c.lastID.Load(); id > 0 {
+		if header == nil {
+			header = http.Header{}
+		}
+		header.Set(server.HeaderLastEventID, strconv.FormatUint(id, 10))
+	}
+	opts := &websocket.DialOptions{HTTPHeader: header}
+	if c.conf.Subprotocol != "" {
+		opts.Subprotocols = []string{c.conf.Subprotocol}
+	}
+	conn, _, err := websocket.Dial(ctx, c.conf.URL, opts)
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (c *Client) setConn(conn *websocket.Conn) {
+	c.mu.Lock()
+	c.conn = conn
+	// 服务端未接受子协议时使用json
+	c.codec = server.EventCodecFor(conn.Subprotocol())
+	c.lost = make(chan struct{})
+	c.sent = make(map[uint64]struct{})
+	c.answered = make(map[uint64]struct{})
+	c.mu.Unlock()
+}
+
+// mark 记录连接上的rid，连接已更换时忽略
+func (c *Client) mark(conn *websocket.Conn, set *map[uint64]struct{}, rid uint64) {
+	c.mu.Lock()
+	if c.conn == conn {
+		(*set)[rid] = struct{}{}
+	}
+	c.mu.Unlock()
+}
+
+// consume 删除连接上记录的rid，返回是否存在
+func (c *Client) consume(conn *websocket.Conn, set *map[uint64]struct{}, rid uint64) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn != conn {
+		return false
+	}
+	if _, ok := (*set)[rid]; !ok {
+		return false
+	}
+	delete(*set, rid)
+	return true
+}
+
+func (c *Client) current() (*websocket.Conn, chan struct{}, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.ctx.Err() != nil {
+		return nil, nil, ErrClosed
+	}
+	if c.conn == nil {
+		return nil, nil, ErrNotConnected
+	}
+	return c.conn, c.lost, nil
+}
+
+func (c *Client) write(ctx stdContext.Context, conn *websocket.Conn, data *server.EventData) error {
+	c.mu.RLock()
+	codec := c.codec
+	c.mu.RUnlock()
+	msg, err := codec.Marshal(data)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := stdContext.WithTimeout(ctx, c.conf.WriteTimeout)
+	defer cancel()
+	return conn.Write(ctx, codec.MessageType(), msg)
+}
+
+// run 读取消息，连接断开后按指数退避重连，直到 Close
+func (c *Client) run(conn *websocket.Conn) {
+	defer close(c.done)
+	for attempt := 0; ; {
+		if conn != nil {
+			attempt = 0
+			if c.conf.OnConnect != nil {
+				go c.conf.OnConnect(c)
+			}
+			c.read(conn)
+			c.mu.Lock()
+			c.conn = nil
+			close(c.lost)
+			c.mu.Unlock()
+			_ = conn.CloseNow()
+		}
+		if c.ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(c.backoff(attempt)):
+		}
+		attempt++
+
+		var err error
+		conn, err = c.dial(c.ctx)
+		if err != nil {
+			conn = nil
+			continue
+		}
+		c.setConn(conn)
+	}
+}
+
+// backoff 第attempt次重连前的等待时间，带随机抖动
+func (c *Client) backoff(attempt int) time.Duration {
+	d := float64(c.conf.ReconnectMin) * math.Pow(2, float64(min(attempt, 30)))
+	d = min(d, float64(c.conf.ReconnectMax))
+	return time.Duration(d/2 + mrand.Float64()*d/2)
+}
+
+func (c *Client) read(conn *websocket.Conn) {
+	for {
+		// Close 时 ctx 结束，Read 返回并关闭连接
+		typ, msg, err := conn.Read(c.ctx)
+		if err != nil {
+			return
+		}
+		c.mu.RLock()
+		codec := c.codec
+		c.mu.RUnlock()
+		if typ == websocket.MessageText {
+			codec = server.EventCodecFor(server.SubprotocolJSON)
+		}
+		var data server.EventData
+		if err := codec.Unmarshal(msg, &data); err != nil {
+			continue
+		}
+		c.dispatch(conn, &data)
+	}
+}
+
+// dispatch 分发服务端消息：推送放入队列按顺序处理，请求的响应在读取循环中交给等待方，
+// 服务端发起的请求在单独的goroutine中处理
+func (c *Client) dispatch(conn *websocket.Conn, data *server.EventData) {
+	if data.RID == 0 {
+		c.msgMu.Lock()
+		c.messages = append(c.messages, data)
+		c.msgMu.Unlock()
+		select {
+		case c.ready <- struct{}{}:
+		default:
+		}
+		return
+	}
+	if c.consume(conn, &c.sent, data.RID) {
+		// 请求的响应，等待方已超时或取消时丢弃，不能作为服务端请求回复
+		_, _ = c.waiter.Put(c.ctx, strconv.FormatUint(data.RID, 10), data)
+		return
+	}
+	if c.consume(conn, &c.answered, data.RID) {
+		// 服务端收到回复后返回的确认
+		return
+	}
+	c.mark(conn, &c.answered, data.RID)
+	go c.reply(conn, data)
+}
+
+// deliver 按接收顺序逐条调用 OnMessage，直到 Close
+func (c *Client) deliver() {
+	defer close(c.drained)
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-c.ready:
+		}
+		for {
+			c.msgMu.Lock()
+			if len(c.messages) == 0 {
+				c.msgMu.Unlock()
+				break
+			}
+			data := c.messages[0]
+			c.messages[0] = nil
+			c.messages = c.messages[1:]
+			c.msgMu.Unlock()
+			if c.ctx.Err() != nil {
+				return
+			}
+			// 重连前队列中的消息可能被再次补发，跳过已处理的
+			if data.ID > 0 && data.ID <= c.lastID.Load() {
+				continue
+			}
+			if c.conf.OnMessage != nil {
+				c.conf.OnMessage(data)
+			}
+			// 处理完成后再记录，重连时从未处理的消息开始补发
+			if data.ID > c.lastID.Load() {
+				c.lastID.Store(data.ID)
+			}
+		}
+	}
+}
+
+// reply 调用 OnRequest 处理服务端发起的请求并发送响应
+func (c *Client) reply(conn *websocket.Conn, data *server.EventData) {
+	var resp *server.EventData
+	if c.conf.OnRequest != nil {
+		resp = c.conf.OnRequest(c.ctx, data)
+	}
+	if resp == nil {
+		resp = &server.EventData{}
+	}
+	resp.RID, resp.URI = data.RID, data.URI
+	if resp.Header == nil {
+		resp.Header = http.Header{}
+	}
+	_ = c.write(c.ctx, conn, resp)
+}
@@ -0,0 +1,291 @@
+package eventclient
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/lazygo/lazygo/server"
+)
+
+func TestClient(t *testing.T) {
+	s := server.New()
+	s.WebSocket("/echo", func(ctx server.Context) error {
+		body, err := io.ReadAll(ctx.Request().Body)
+		if err != nil {
+			return err
+		}
+		return ctx.Blob(http.StatusOK, server.MIMEApplicationJSON, body)
+	})
+	s.Post("/echo", func(ctx server.Context) error {
+		return ctx.Blob(http.StatusOK, server.MIMEApplicationJSON, []byte(`"post"`))
+	})
+	ev := s.Event(server.MethodWebSocket, "/ws")
+	var cid atomic.Uint64
+	var last atomic.Pointer[websocket.Conn]
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{Subprotocols: server.WebSocketSubprotocols()})
+		if err != nil {
+			return
+		}
+		last.Store(conn)
+		_ = ev.Serve(r.Context(), cid.Add(1), server.WebSocketWrapper(r.Context(), conn))
+	}))
+	defer srv.Close()
+
+	connected := make(chan struct{}, 4)
+	messages := make(chan *server.EventData, 4)
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, Config{
+		URL:          "ws" + srv.URL[len("http"):],
+		Subprotocol:  server.SubprotocolMsgpack,
+		ReconnectMin: 10 * time.Millisecond,
+		OnConnect:    func(*Client) { connected <- struct{}{} },
+		OnMessage:    func(data *server.EventData) { messages <- data },
+		OnRequest: func(ctx stdContext.Context, req *server.EventData) *server.EventData {
+			return &server.EventData{Body: json.RawMessage(`"pong"`)}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	<-connected
+
+	resp, err := c.Request(ctx, "", "/echo", map[string]any{"a": 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != `{"a":1}` {
+		t.Errorf("echo body = %q", resp.Body)
+	}
+	// 指定请求方法时按该方法路由
+	resp, err = c.Request(ctx, http.MethodPost, "/echo", nil)
+	if err != nil || string(resp.Body) != `"post"` {
+		t.Errorf("post echo = %+v, %v", resp, err)
+	}
+
+	// 服务端推送
+	if err := ev.Broadcast(ctx, &server.EventData{URI: "/notice", Header: http.Header{}}); err != nil {
+		t.Fatal(err)
+	}
+	if data := <-messages; data.URI != "/notice" {
+		t.Errorf("message uri = %s", data.URI)
+	}
+
+	// 服务端发起请求
+	wait, err := ev.Request(ctx, 1, &server.EventData{RID: 1, URI: "/ping", Header: http.Header{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := wait(); err != nil || string(data.Body) != `"pong"` {
+		t.Errorf("server request = %+v, %v", data, err)
+	}
+
+	// 服务端断开后自动重连
+	_ = last.Load().CloseNow()
+	select {
+	case <-connected:
+	case <-ctx.Done():
+		t.Fatal("client not reconnected")
+	}
+	if _, err := c.Request(ctx, "", "/echo", map[string]any{}); err != nil {
+		t.Errorf("request after reconnect: %v", err)
+	}
+}
+
+func TestClientServerRequestRoundTrip(t *testing.T) {
+	s := server.New()
+	var served atomic.Int32
+	s.WebSocket("/ping", func(ctx server.Context) error {
+		served.Add(1)
+		return ctx.NoContent(http.StatusOK)
+	})
+	s.WebSocket("/slow", func(ctx server.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return ctx.Blob(http.StatusOK, server.MIMEApplicationJSON, []byte(`"late"`))
+	})
+	ev := s.Event(server.MethodWebSocket, "/ws")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = ev.Serve(r.Context(), 1, server.WebSocketWrapper(r.Context(), conn))
+	}))
+	defer srv.Close()
+
+	var requests atomic.Int32
+	connected := make(chan struct{}, 1)
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, Config{
+		URL:       "ws" + srv.URL[len("http"):],
+		OnConnect: func(*Client) { connected <- struct{}{} },
+		OnRequest: func(ctx stdContext.Context, req *server.EventData) *server.EventData {
+			requests.Add(1)
+			return &server.EventData{Body: json.RawMessage(`"pong"`)}
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	<-connected
+
+	wait, err := ev.Request(ctx, 1, &server.EventData{RID: 1, URI: "/ping", Header: http.Header{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, err := wait(); err != nil || string(data.Body) != `"pong"` {
+		t.Fatalf("server request = %+v, %v", data, err)
+	}
+
+	// 客户端请求超时后到达的响应不能被当作服务端请求
+	reqCtx, reqCancel := stdContext.WithTimeout(ctx, 20*time.Millisecond)
+	defer reqCancel()
+	if _, err := c.Request(reqCtx, "", "/slow", nil); err == nil {
+		t.Fatal("slow request should time out")
+	}
+
+	time.Sleep(300 * time.Millisecond)
+	if n := requests.Load(); n != 1 {
+		t.Errorf("OnRequest called %d times, want 1", n)
+	}
+	if n := served.Load(); n != 0 {
+		t.Errorf("client response served as request %d times", n)
+	}
+}
+
+func TestClientMessageOrder(t *testing.T) {
+	s := server.New()
+	ev := s.Event(server.MethodWebSocket, "/ws")
+	ev.SetEventLog(server.NewMemoryEventLog(100))
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = ev.Serve(r.Context(), 1, server.WebSocketWrapper(r.Context(), conn))
+	}))
+	defer srv.Close()
+
+	const n = 50
+	var delivered atomic.Uint64
+	var inflight atomic.Int32
+	lastIDs := make([]uint64, 0, n)
+	uris := make(chan string, n)
+	connected := make(chan struct{}, 1)
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 5*time.Second)
+	defer cancel()
+	var c *Client
+	var err error
+	c, err = Dial(ctx, Config{
+		URL:       "ws" + srv.URL[len("http"):],
+		OnConnect: func(*Client) { connected <- struct{}{} },
+		OnMessage: func(data *server.EventData) {
+			if inflight.Add(1) > 1 {
+				t.Error("OnMessage called concurrently")
+			}
+			if data.ID%3 == 0 {
+				time.Sleep(time.Millisecond)
+			}
+			lastIDs = append(lastIDs, c.LastEventID())
+			delivered.Store(data.ID)
+			inflight.Add(-1)
+			uris <- data.URI
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	<-connected
+
+	for i := range n {
+		if err := ev.Broadcast(ctx, &server.EventData{URI: "/m/" + strconv.Itoa(i), Header: http.Header{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := range n {
+		if uri := <-uris; uri != "/m/"+strconv.Itoa(i) {
+			t.Fatalf("message %d uri = %s", i, uri)
+		}
+	}
+	// 处理消息时 LastEventID 还是上一条消息的id
+	for i, id := range lastIDs {
+		if id != uint64(i) {
+			t.Fatalf("LastEventID during message %d = %d", i+1, id)
+		}
+	}
+	for i := 0; c.LastEventID() != delivered.Load(); i++ {
+		if i > 100 {
+			t.Fatalf("LastEventID = %d, delivered %d", c.LastEventID(), delivered.Load())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestClientSlowOnMessage(t *testing.T) {
+	s := server.New()
+	s.WebSocket("/ping", func(ctx server.Context) error {
+		return ctx.Blob(http.StatusOK, server.MIMEApplicationJSON, []byte(`"pong"`))
+	})
+	ev := s.Event(server.MethodWebSocket, "/ws")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		_ = ev.Serve(r.Context(), 1, server.WebSocketWrapper(r.Context(), conn))
+	}))
+	defer srv.Close()
+
+	release := make(chan struct{})
+	received := make(chan string, 2)
+	connected := make(chan struct{}, 1)
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 5*time.Second)
+	defer cancel()
+	c, err := Dial(ctx, Config{
+		URL:       "ws" + srv.URL[len("http"):],
+		OnConnect: func(*Client) { connected <- struct{}{} },
+		OnMessage: func(data *server.EventData) {
+			<-release
+			received <- data.URI
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	var once sync.Once
+	unblock := func() { once.Do(func() { close(release) }) }
+	defer unblock()
+	<-connected
+
+	for _, uri := range []string{"/a", "/b"} {
+		if err := ev.Broadcast(ctx, &server.EventData{URI: uri, Header: http.Header{}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// OnMessage 阻塞时请求的响应仍能送达
+	reqCtx, reqCancel := stdContext.WithTimeout(ctx, time.Second)
+	defer reqCancel()
+	resp, err := c.Request(reqCtx, "", "/ping", nil)
+	if err != nil || string(resp.Body) != `"pong"` {
+		t.Fatalf("request while OnMessage blocked = %+v, %v", resp, err)
+	}
+	unblock()
+	if a, b := <-received, <-received; a != "/a" || b != "/b" {
+		t.Errorf("messages = %s, %s", a, b)
+	}
+}
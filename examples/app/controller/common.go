@@ -77,7 +77,8 @@ func (s *CommonController) Upload(req *request.ToolsUploadRequest) (any, error)
 func (ctl *CommonController) Connection(req *request.ConnectionRequest) error {
 	opts := &websocket.AcceptOptions{
 		OriginPatterns: []string{"*"},
-		//Subprotocols:    []string{"lazygo"},
+		// 客户端可通过子协议选择 lazygo.msgpack 或 lazygo.cbor 二进制编码，默认json
+		Subprotocols:    server.WebSocketSubprotocols(),
 		CompressionMode: websocket.CompressionContextTakeover,
 	}
 	w := ctl.Ctx.ResponseWriter()
@@ -90,5 +91,7 @@ func (ctl *CommonController) Connection(req *request.ConnectionRequest) error {
 
 	sub := fmt.Sprintf("uid:%d", req.UID)
 	ev := ctl.Ctx.Event(server.MethodWebSocket, sub)
-	return ev.Serve(ctl.Ctx, ctl.Ctx.RequestID(), server.WebSocketWrapper(ctl.Ctx, conn))
+	// 客户端重连时携带 Last-Event-ID，补发断线期间的广播消息
+	lastID := server.LastEventID(ctl.Ctx.Request())
+	return ev.ServeFrom(ctl.Ctx, ctl.Ctx.RequestID(), server.WebSocketWrapper(ctl.Ctx, conn), lastID)
 }
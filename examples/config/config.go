@@ -16,6 +16,7 @@ import (
 	"github.com/lazygo/lazygo/memory"
 	"github.com/lazygo/lazygo/redis"
 	"github.com/lazygo/lazygo/sqldb"
+	"github.com/lazygo/lazygo/trace"
 	"github.com/lazygo/pkg/cos"
 	"github.com/lazygo/pkg/mail"
 	"github.com/lazygo/pkg/sms"
@@ -42,9 +43,18 @@ type HTTPDNS struct {
 	Adapter     []httpdns.Config `json:"adapter" toml:"adapter"`
 }
 
+type Trace struct {
+	ServiceName string  `json:"service_name" toml:"service_name"`
+	Exporter    string  `json:"exporter" toml:"exporter"` // stdout 或 otlp，为空时只传递链路信息
+	Endpoint    string  `json:"endpoint" toml:"endpoint"` // otlp 采集器地址，如 http://127.0.0.1:4318
+	SampleRatio float64 `json:"sample_ratio" toml:"sample_ratio"`
+}
+
 type Server struct {
-	Addr  string `json:"addr" toml:"addr"`
-	Debug bool   `json:"debug" toml:"debug"`
+	Addr           string   `json:"addr" toml:"addr"`
+	Debug          bool     `json:"debug" toml:"debug"`
+	TrustedProxies []string `json:"trusted_proxies" toml:"trusted_proxies"`
+	ClientIPHeader string   `json:"client_ip_header" toml:"client_ip_header"`
 }
 
 var ServerConfig Server
@@ -159,6 +169,22 @@ func Init(filename string) error {
 		return fmt.Errorf("init wechat official account fail: %w", err)
 	}
 
+	// load trace config
+	err = base.Load("trace", func(conf Trace) error {
+		tc := trace.Config{ServiceName: conf.ServiceName, SampleRatio: conf.SampleRatio}
+		switch conf.Exporter {
+		case "stdout":
+			tc.Exporter = trace.NewStdoutExporter(os.Stdout)
+		case "otlp":
+			tc.Exporter = trace.NewOTLPExporter(trace.OTLPConfig{Endpoint: conf.Endpoint})
+		}
+		trace.Init(tc)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("init trace fail: %w", err)
+	}
+
 	// load server config
 	err = base.Load("server", func(conf Server) error {
 		ServerConfig = conf
@@ -1,9 +1,7 @@
 package framework
 
 import (
-	"net"
 	"net/http"
-	"strings"
 	"time"
 
 	stdContext "context"
@@ -21,7 +19,6 @@ type Context interface {
 	Logger() Logger
 	RequestID() uint64
 	UID() uint64
-	RealIP() string
 	Succ(any) error
 }
 
@@ -48,31 +45,6 @@ func (c *context) UID() uint64 {
 	return uid
 }
 
-// RealIP 获取请求客户端IP
-func (c *context) RealIP() string {
-	if realIP, ok := c.Value(server.HeaderXRealIP).(string); ok {
-		return realIP
-	}
-	// Fall back to legacy behavior
-	if ip := c.RequestHeader(server.HeaderXForwardedFor); ip != "" {
-		i := strings.IndexAny(ip, ",")
-		if i > 0 {
-			xffip := strings.TrimSpace(ip[:i])
-			xffip = strings.TrimPrefix(xffip, "[")
-			xffip = strings.TrimSuffix(xffip, "]")
-			return xffip
-		}
-		return ip
-	}
-	if ip := c.RequestHeader(server.HeaderXRealIP); ip != "" {
-		ip = strings.TrimPrefix(ip, "[")
-		ip = strings.TrimSuffix(ip, "]")
-		return ip
-	}
-	ra, _, _ := net.SplitHostPort(c.Request().RemoteAddr)
-	return ra
-}
-
 // Succ 返回成功
 func (c *context) Succ(data any) error {
 	resp := Response[any]{
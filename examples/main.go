@@ -15,6 +15,7 @@ import (
 	"github.com/lazygo/lazygo/examples/config"
 	"github.com/lazygo/lazygo/examples/framework"
 	"github.com/lazygo/lazygo/examples/router"
+	"github.com/lazygo/lazygo/trace"
 )
 
 func init() {
@@ -35,6 +36,11 @@ func main() {
 
 	httpServer := framework.Server()
 	httpServer.Debug = config.ServerConfig.Debug
+	httpServer.ClientIPHeader = config.ServerConfig.ClientIPHeader
+	err = httpServer.SetTrustedProxies(config.ServerConfig.TrustedProxies...)
+	if err != nil {
+		log.Fatalf("[msg: set trusted proxies error] [err: %v]", err)
+	}
 
 	ctx := context.Background()
 
@@ -61,4 +67,7 @@ func main() {
 		log.Printf("[msg: shutting down the server] [err: %v]", err)
 		httpServer.Logger.Fatal(err)
 	}
+	if err := trace.Shutdown(ctx); err != nil {
+		log.Printf("[msg: shutting down the tracer] [err: %v]", err)
+	}
 }
@@ -1,6 +1,8 @@
 package router
 
 import (
+	"github.com/lazygo/lazygo/health"
+	lazymiddleware "github.com/lazygo/lazygo/middleware"
 	"github.com/lazygo/lazygo/server"
 
 	"github.com/lazygo/lazygo/examples/app/controller"
@@ -19,21 +21,27 @@ func Init(app *server.Server) *server.Server {
 	// 添加request_id
 	app.Use(middleware.RequestID)
 
-	// 添加TrustProxies
-	app.Use(middleware.TrustProxies)
-
 	// 支持解压body
 	app.Use(middleware.DecompressRequest)
 
+	// 解析 traceparent 并创建服务端span
+	app.Use(lazymiddleware.Tracing(lazymiddleware.TracingConfig{}))
+
 	// 增加访问日志记录
 	app.Use(middleware.AccessLog)
 
+	// 按路由记录请求数、耗时和响应大小，通过 /internal/metrics 采集
+	app.Use(lazymiddleware.Metrics(lazymiddleware.MetricsConfig{}))
+
 	// Debug 模式开启跨域支持
 	if app.Debug {
 		app.Use(middleware.Debug)
 	}
 
 	app.Get("/", server.NotFoundHandler)
+	// 健康检查，带 verbose 参数时输出每项检查的json结果
+	app.Get("/livez", server.WrapHandler(health.LivezHandler()))
+	app.Get("/readyz", server.WrapHandler(health.ReadyzHandler()))
 	connHandler := server.Controller(controller.CommonController{}, "Connection")
 	app.Get("connection/:token", connHandler, middleware.User, middleware.AuthUser)
 
@@ -2,6 +2,7 @@ package router
 
 import (
 	"github.com/lazygo/lazygo/examples/app/controller"
+	"github.com/lazygo/lazygo/metrics"
 	"github.com/lazygo/lazygo/server"
 )
 
@@ -12,4 +13,14 @@ func InnerRouter(g *server.Group) {
 		sg.Get("client_ip", server.Controller(controller.DebugController{}, "ClientIP"))
 	}
 
+	g.Get("metrics", server.WrapHandler(metrics.Handler()))
+
+	// 内部服务通过 JSON-RPC 调用，如 {"jsonrpc":"2.0","method":"user.profile","id":1}
+	g.Post("rpc", server.JSONRPC(server.JSONRPCConfig{
+		Services: map[string]any{
+			"user":  controller.UserController{},
+			"debug": controller.DebugController{},
+		},
+	}))
+
 }
@@ -23,4 +23,16 @@ func ThirdRouter(g *server.Group) {
 	// app.Any("/api/payment/:pay_way/notify", server.Controller(controller.PaymentController{}), ErrTo500)
 	// app.Any("/api/wechat/notify", server.Controller(controller.WechatController{}), ErrTo500)
 
+	// 旧服务反向代理 /api/legacy/* -> http://legacy-a:8080/*
+	// a, _ := server.NewProxyTarget("http://legacy-a:8080")
+	// b, _ := server.NewProxyTarget("http://legacy-b:8080")
+	// proxy, _ := server.NewProxy(server.ProxyConfig{
+	// 	Targets:     []*server.ProxyTarget{a, b},
+	// 	Balancer:    server.NewLeastConnBalancer(),
+	// 	Transport:   httpclient.New(&httpclient.Config{HTTPDNSAdapter: "baidu"}).Transport(&httpclient.HttpConfig{}),
+	// 	Rewrite:     map[string]string{"/api/legacy/*": "/$1"},
+	// 	HealthCheck: server.ProxyHealthCheck{Path: "/healthz"},
+	// })
+	// g.Any("/legacy/*", proxy.Handle)
+
 }
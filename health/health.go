@@ -0,0 +1,310 @@
+// Package health 健康检查，提供存活检查（/livez）和就绪检查（/readyz）
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout  = 2 * time.Second
+	defaultCacheTTL = time.Second
+)
+
+var ErrCheckTimeout = errors.New("health check timeout")
+
+// Checker 检查函数，返回nil表示健康
+type Checker func(ctx context.Context) error
+
+// Pinger 可以检查连接状态的实例，如数据库、缓存适配器
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// Kind 检查类型
+type Kind int
+
+const (
+	// Readiness 就绪检查，失败时不应接收流量，如依赖的数据库不可用
+	Readiness Kind = 1 << iota
+	// Liveness 存活检查，失败时应重启进程，如死锁
+	Liveness
+)
+
+type Check struct {
+	// Name 检查名称，相同名称会覆盖，如 sqldb/main
+	Name string
+	// Kind 检查类型，可以同时为 Readiness|Liveness，默认 Readiness
+	Kind Kind
+	// Timeout 单次检查的超时时间，默认使用 Config.Timeout
+	Timeout time.Duration
+	// CacheTTL 检查结果的缓存时间，默认使用 Config.CacheTTL，小于0时不缓存
+	CacheTTL time.Duration
+	// Func 检查函数
+	Func Checker
+}
+
+type Config struct {
+	// Timeout 单次检查的默认超时时间，默认2秒
+	Timeout time.Duration
+	// CacheTTL 检查结果的默认缓存时间，避免探针频繁请求时压垮依赖，默认1秒
+	CacheTTL time.Duration
+}
+
+// Result 单项检查结果
+type Result struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Error      string    `json:"error,omitempty"`
+	DurationMs float64   `json:"duration_ms"`
+	CheckedAt  time.Time `json:"checked_at"`
+	Cached     bool      `json:"cached,omitempty"`
+}
+
+// Report 检查报告
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// OK 所有检查都通过
+func (r *Report) OK() bool {
+	return r.Status == StatusOK
+}
+
+const (
+	StatusOK   = "ok"
+	StatusFail = "fail"
+)
+
+type entry struct {
+	check Check
+	mu    sync.Mutex
+	last  Result
+	// running 正在执行的检查，结束时关闭
+	running chan struct{}
+}
+
+// Registry 健康检查注册表
+type Registry struct {
+	conf   Config
+	mu     sync.RWMutex
+	checks map[string]*entry
+}
+
+// NewRegistry 创建健康检查注册表
+func NewRegistry(conf Config) *Registry {
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultTimeout
+	}
+	if conf.CacheTTL == 0 {
+		conf.CacheTTL = defaultCacheTTL
+	}
+	return &Registry{conf: conf, checks: make(map[string]*entry)}
+}
+
+// Default 默认注册表，各组件初始化时将检查注册在此
+var Default = NewRegistry(Config{})
+
+// Add 添加检查
+func (r *Registry) Add(c Check) {
+	if c.Name == "" || c.Func == nil {
+		panic("health: check requires name and func")
+	}
+	if c.Kind == 0 {
+		c.Kind = Readiness
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = r.conf.Timeout
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = r.conf.CacheTTL
+	}
+	r.mu.Lock()
+	r.checks[c.Name] = &entry{check: c}
+	r.mu.Unlock()
+}
+
+// Register 添加就绪检查
+func (r *Registry) Register(name string, fn Checker) {
+	r.Add(Check{Name: name, Func: fn})
+}
+
+// Remove 移除检查
+func (r *Registry) Remove(name string) {
+	r.mu.Lock()
+	delete(r.checks, name)
+	r.mu.Unlock()
+}
+
+// Run 并发执行指定类型的所有检查
+func (r *Registry) Run(ctx context.Context, kind Kind) *Report {
+	r.mu.RLock()
+	var list []*entry
+	for _, e := range r.checks {
+		if e.check.Kind&kind != 0 {
+			list = append(list, e)
+		}
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(list, func(a, b *entry) int {
+		return strings.Compare(a.check.Name, b.check.Name)
+	})
+
+	report := &Report{Status: StatusOK, Checks: make([]Result, len(list))}
+	var wg sync.WaitGroup
+	for i, e := range list {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			report.Checks[i] = e.run(ctx)
+		}()
+	}
+	wg.Wait()
+	for _, res := range report.Checks {
+		if res.Status != StatusOK {
+			report.Status = StatusFail
+		}
+	}
+	return report
+}
+
+// run 执行检查，缓存有效期内直接返回上次的结果；并发请求等待同一次检查，检查在锁外执行
+func (e *entry) run(ctx context.Context) Result {
+	e.mu.Lock()
+	if !e.last.CheckedAt.IsZero() && e.check.CacheTTL > 0 && time.Since(e.last.CheckedAt) < e.check.CacheTTL {
+		res := e.last
+		res.Cached = true
+		e.mu.Unlock()
+		return res
+	}
+	if running := e.running; running != nil {
+		e.mu.Unlock()
+		select {
+		case <-running:
+			e.mu.Lock()
+			defer e.mu.Unlock()
+			return e.last
+		case <-ctx.Done():
+			return Result{Name: e.check.Name, Status: StatusFail, Error: ctx.Err().Error(), CheckedAt: time.Now()}
+		}
+	}
+	running := make(chan struct{})
+	e.running = running
+	e.mu.Unlock()
+
+	res := e.exec(ctx)
+	e.mu.Lock()
+	e.last = res
+	e.running = nil
+	e.mu.Unlock()
+	close(running)
+	return res
+}
+
+// exec 执行检查函数，超时后不再等待
+func (e *entry) exec(ctx context.Context) Result {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, e.check.Timeout)
+	defer cancel()
+	// 检查函数可能不响应ctx，超时后不再等待
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- e.check.Func(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ErrCheckTimeout
+	}
+
+	res := Result{
+		Name:       e.check.Name,
+		Status:     StatusOK,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		CheckedAt:  time.Now(),
+	}
+	if err != nil {
+		res.Status = StatusFail
+		res.Error = err.Error()
+	}
+	return res
+}
+
+// LivezHandler 存活检查接口
+func (r *Registry) LivezHandler() http.Handler {
+	return r.handler(Liveness)
+}
+
+// ReadyzHandler 就绪检查接口
+func (r *Registry) ReadyzHandler() http.Handler {
+	return r.handler(Readiness)
+}
+
+// handler 全部通过时返回200，否则返回503
+// 默认输出纯文本，带 verbose 参数时输出json格式的每项检查结果
+func (r *Registry) handler(kind Kind) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Run(req.Context(), kind)
+		code := http.StatusOK
+		if !report.OK() {
+			code = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		if req.URL.Query().Has("verbose") {
+			w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+			w.WriteHeader(code)
+			_ = json.NewEncoder(w).Encode(report)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=UTF-8")
+		w.WriteHeader(code)
+		if report.OK() {
+			_, _ = w.Write([]byte(StatusOK + "\n"))
+			return
+		}
+		for _, res := range report.Checks {
+			if res.Status != StatusOK {
+				fmt.Fprintf(w, "[-] %s failed: %s\n", res.Name, res.Error)
+			}
+		}
+	})
+}
+
+// Add 在默认注册表添加检查
+func Add(c Check) {
+	Default.Add(c)
+}
+
+// Register 在默认注册表添加就绪检查
+func Register(name string, fn Checker) {
+	Default.Register(name, fn)
+}
+
+// Remove 从默认注册表移除检查
+func Remove(name string) {
+	Default.Remove(name)
+}
+
+// LivezHandler 默认注册表的存活检查接口
+func LivezHandler() http.Handler {
+	return Default.LivezHandler()
+}
+
+// ReadyzHandler 默认注册表的就绪检查接口
+func ReadyzHandler() http.Handler {
+	return Default.ReadyzHandler()
+}
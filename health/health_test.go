@@ -0,0 +1,101 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHealth(t *testing.T) {
+	r := NewRegistry(Config{Timeout: 50 * time.Millisecond, CacheTTL: time.Hour})
+
+	var calls atomic.Int32
+	var fail atomic.Bool
+	r.Register("sqldb/main", func(ctx context.Context) error {
+		calls.Add(1)
+		if fail.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	r.Add(Check{Name: "deadlock", Kind: Liveness, Func: func(context.Context) error { return nil }})
+
+	do := func(h http.Handler, target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	w := do(r.ReadyzHandler(), "/readyz")
+	if w.Code != http.StatusOK || w.Body.String() != "ok\n" {
+		t.Fatalf("readyz %d %q", w.Code, w.Body.String())
+	}
+	// 缓存有效期内不再执行检查
+	fail.Store(true)
+	do(r.ReadyzHandler(), "/readyz")
+	if calls.Load() != 1 {
+		t.Fatalf("check should be cached, calls %d", calls.Load())
+	}
+
+	// 不缓存时立即反映失败
+	r.Add(Check{Name: "sqldb/main", CacheTTL: -1, Func: func(context.Context) error { return errors.New("connection refused") }})
+	r.Add(Check{Name: "slow", CacheTTL: -1, Func: func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}})
+	w = do(r.ReadyzHandler(), "/readyz")
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "[-] sqldb/main failed: connection refused") {
+		t.Fatalf("readyz %d %q", w.Code, w.Body.String())
+	}
+
+	w = do(r.ReadyzHandler(), "/readyz?verbose")
+	var report Report
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.Status != StatusFail || len(report.Checks) != 2 || report.Checks[0].Name != "slow" || report.Checks[0].Error != ErrCheckTimeout.Error() {
+		t.Fatalf("unexpected report %+v", report)
+	}
+
+	// 存活检查只执行 Liveness 类型的检查
+	w = do(r.LivezHandler(), "/livez?verbose")
+	report = Report{}
+	_ = json.Unmarshal(w.Body.Bytes(), &report)
+	if w.Code != http.StatusOK || len(report.Checks) != 1 || report.Checks[0].Name != "deadlock" {
+		t.Fatalf("livez %d %s", w.Code, w.Body.String())
+	}
+}
+
+func TestHealthConcurrent(t *testing.T) {
+	r := NewRegistry(Config{Timeout: time.Second, CacheTTL: -1})
+	var calls atomic.Int32
+	r.Register("slow", func(context.Context) error {
+		calls.Add(1)
+		time.Sleep(100 * time.Millisecond)
+		return nil
+	})
+
+	// 并发的探针等待同一次检查，不在锁内排队
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if report := r.Run(context.Background(), Readiness); !report.OK() {
+				t.Errorf("report %+v", report)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond || calls.Load() != 1 {
+		t.Fatalf("elapsed %v, calls %d", elapsed, calls.Load())
+	}
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/andybalholm/brotli"
 	"github.com/klauspost/compress/zstd"
 	"github.com/lazygo/lazygo/server"
+	"github.com/lazygo/lazygo/trace"
 )
 
 const (
@@ -155,8 +156,11 @@ func (hc *Client) Request(ctx context.Context, httpMethod string, url string, bo
 		if err != nil {
 			return nil, 0, err
 		}
-		req, err := http.NewRequestWithContext(ctx, httpMethod, url, reqBody)
+		// 每次请求（包括重试）一个span，并通过 traceparent 传递给下游
+		spanCtx, span := trace.Start(ctx, trace.KindClient, "HTTP "+httpMethod)
+		req, err := http.NewRequestWithContext(spanCtx, httpMethod, url, reqBody)
 		if err != nil {
+			span.End()
 			return nil, 0, err
 		}
 		// request header
@@ -164,6 +168,7 @@ func (hc *Client) Request(ctx context.Context, httpMethod string, url string, bo
 			if k == server.HeaderContentLength {
 				req.ContentLength, err = strconv.ParseInt(v, 10, 64)
 				if err != nil {
+					span.End()
 					return nil, 0, fmt.Errorf("Content-Length error: %w", err)
 				}
 				continue
@@ -174,10 +179,23 @@ func (hc *Client) Request(ctx context.Context, httpMethod string, url string, bo
 			}
 			req.Header.Add(k, v)
 		}
+		trace.Inject(spanCtx, req.Header)
+		span.SetAttr("http.request.method", httpMethod).
+			SetAttr("server.address", req.URL.Host).
+			SetAttr("url.full", req.URL.Redacted())
+		if i > 0 {
+			span.SetAttr("http.request.resend_count", i)
+		}
 		resp, err = hc.Do(req)
 		if err == nil {
+			span.SetAttr("http.response.status_code", resp.StatusCode)
+			if resp.StatusCode >= http.StatusInternalServerError {
+				span.SetStatus(trace.StatusError, resp.Status)
+			}
+			span.End()
 			break
 		}
+		span.SetError(err).End()
 		if i == retryTimes {
 			return nil, 0, err
 		}
@@ -209,7 +209,7 @@ func resolver(dns string) *net.Resolver {
 }
 
 func (m *Manager) Client(config *HttpConfig) *Client {
-	client := http.Client{Transport: m.Transport(config)}
+	client := http.Client{Transport: WithMetrics(m.Transport(config))}
 	client.Timeout = config.Timeout
 	return &Client{
 		Client: client,
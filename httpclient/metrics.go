@@ -0,0 +1,36 @@
+package httpclient
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lazygo/lazygo/metrics"
+)
+
+var upstreamDuration = metrics.NewHistogramVec("lazygo_httpclient_request_duration_seconds",
+	"Upstream HTTP request latency in seconds.", nil, "host", "method", "status")
+
+// metricsTransport 记录上游请求耗时，请求失败时status为error
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+// WithMetrics 包装 RoundTripper，按上游host、method和状态码记录请求耗时
+func WithMetrics(rt http.RoundTripper) http.RoundTripper {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	return &metricsTransport{next: rt}
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+	upstreamDuration.With(req.URL.Host, req.Method, status).Observe(time.Since(start).Seconds())
+	return resp, err
+}
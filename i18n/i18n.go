@@ -0,0 +1,298 @@
+// Package i18n 多语言消息目录，支持复数形式和占位符
+package i18n
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("unsupported catalog format")
+	ErrInvalidMessage    = errors.New("invalid catalog message")
+)
+
+// Params 占位符参数，消息中的 {name} 替换为 Params["name"]
+type Params map[string]any
+
+// message 单条消息，复数消息按类别保存，如 one、other
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+// Bundle 所有语言的消息目录
+type Bundle struct {
+	mu       sync.RWMutex
+	fallback string
+	messages map[string]map[string]*message
+}
+
+// NewBundle 创建消息目录，fallback 为找不到消息时使用的默认语言，如 zh-CN
+func NewBundle(fallback string) *Bundle {
+	return &Bundle{
+		fallback: Canonical(fallback),
+		messages: make(map[string]map[string]*message),
+	}
+}
+
+// Fallback 默认语言
+func (b *Bundle) Fallback() string {
+	return b.fallback
+}
+
+// Locales 已加载的语言
+func (b *Bundle) Locales() []string {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	list := make([]string, 0, len(b.messages))
+	for locale := range b.messages {
+		list = append(list, locale)
+	}
+	slices.Sort(list)
+	return list
+}
+
+// Add 添加消息，已存在时覆盖
+func (b *Bundle) Add(locale, key, text string) {
+	b.add(Canonical(locale), key, &message{text: text})
+}
+
+// AddPlural 添加复数消息，forms 的key为复数类别：zero、one、two、few、many、other
+func (b *Bundle) AddPlural(locale, key string, forms map[string]string) {
+	b.add(Canonical(locale), key, &message{plural: forms})
+}
+
+func (b *Bundle) add(locale, key string, m *message) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.messages[locale] == nil {
+		b.messages[locale] = make(map[string]*message)
+	}
+	b.messages[locale][key] = m
+}
+
+// LoadTOML 加载TOML格式的消息目录，嵌套的表使用 . 连接为key；
+// 只包含复数类别的表为复数消息，如
+//
+//	[cart]
+//	empty = "购物车是空的"
+//	[cart.items]
+//	other = "{count} 件商品"
+func (b *Bundle) LoadTOML(locale string, data []byte) error {
+	var m map[string]any
+	if err := toml.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	return b.load(Canonical(locale), "", m)
+}
+
+// LoadJSON 加载JSON格式的消息目录，格式与 LoadTOML 相同
+func (b *Bundle) LoadJSON(locale string, data []byte) error {
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	return b.load(Canonical(locale), "", m)
+}
+
+// LoadFS 加载目录中所有的 .toml 和 .json 文件，文件名为语言，如 locales/zh-CN.toml
+func (b *Bundle) LoadFS(fsys fs.FS, dir string) error {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		name := entry.Name()
+		ext := path.Ext(name)
+		if ext != ".toml" && ext != ".json" {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, path.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		if err := b.LoadFile(name, data); err != nil {
+			return fmt.Errorf("load %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// LoadFile 按文件名加载消息目录，文件名为语言，扩展名为格式
+func (b *Bundle) LoadFile(name string, data []byte) error {
+	ext := path.Ext(name)
+	locale := strings.TrimSuffix(path.Base(name), ext)
+	switch ext {
+	case ".toml":
+		return b.LoadTOML(locale, data)
+	case ".json":
+		return b.LoadJSON(locale, data)
+	}
+	return ErrUnsupportedFormat
+}
+
+func (b *Bundle) load(locale, prefix string, m map[string]any) error {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case string:
+			b.add(locale, key, &message{text: v})
+		case map[string]any:
+			if forms, ok := pluralForms(v); ok {
+				b.add(locale, key, &message{plural: forms})
+				continue
+			}
+			if err := b.load(locale, key, v); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%w: %s", ErrInvalidMessage, key)
+		}
+	}
+	return nil
+}
+
+// pluralForms 表中只包含复数类别且值都为字符串时为复数消息
+func pluralForms(m map[string]any) (map[string]string, bool) {
+	if _, ok := m[PluralOther]; !ok {
+		return nil, false
+	}
+	forms := make(map[string]string, len(m))
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok || !slices.Contains(pluralCategories, k) {
+			return nil, false
+		}
+		forms[k] = s
+	}
+	return forms, true
+}
+
+// lookup 按语言顺序查找消息，每种语言依次尝试完整语言和基础语言，如 zh-TW、zh，最后使用默认语言
+func (b *Bundle) lookup(locales []string, key string) (*message, string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	try := func(locale string) (*message, bool) {
+		m, ok := b.messages[locale][key]
+		return m, ok
+	}
+	for _, locale := range slices.Concat(locales, []string{b.fallback}) {
+		locale = Canonical(locale)
+		if m, ok := try(locale); ok {
+			return m, locale, true
+		}
+		if base := Base(locale); base != locale {
+			if m, ok := try(base); ok {
+				return m, base, true
+			}
+		}
+	}
+	return nil, "", false
+}
+
+// Localizer 按语言优先级翻译消息
+type Localizer struct {
+	bundle  *Bundle
+	locales []string
+}
+
+// Localizer 创建翻译器，locales 按优先级排列
+func (b *Bundle) Localizer(locales ...string) *Localizer {
+	return &Localizer{bundle: b, locales: locales}
+}
+
+// Locale 优先使用的语言
+func (l *Localizer) Locale() string {
+	if len(l.locales) > 0 {
+		return Canonical(l.locales[0])
+	}
+	return l.bundle.fallback
+}
+
+// Lookup 翻译消息，找不到时返回false
+func (l *Localizer) Lookup(key string, params Params) (string, bool) {
+	m, locale, ok := l.bundle.lookup(l.locales, key)
+	if !ok {
+		return key, false
+	}
+	text := m.text
+	if m.plural != nil {
+		text = m.form(locale, params["count"])
+	}
+	return format(text, params), true
+}
+
+// T 翻译消息，找不到时返回key
+func (l *Localizer) T(key string, params ...Params) string {
+	var p Params
+	if len(params) > 0 {
+		p = params[0]
+	}
+	text, _ := l.Lookup(key, p)
+	return text
+}
+
+// N 翻译复数消息，count 同时作为占位符 {count}
+func (l *Localizer) N(key string, count int, params ...Params) string {
+	p := Params{}
+	if len(params) > 0 {
+		for k, v := range params[0] {
+			p[k] = v
+		}
+	}
+	p["count"] = count
+	text, _ := l.Lookup(key, p)
+	return text
+}
+
+// T 使用指定语言翻译消息
+func (b *Bundle) T(locale, key string, params ...Params) string {
+	return b.Localizer(locale).T(key, params...)
+}
+
+// N 使用指定语言翻译复数消息
+func (b *Bundle) N(locale, key string, count int, params ...Params) string {
+	return b.Localizer(locale).N(key, count, params...)
+}
+
+// format 替换 {name} 占位符，没有对应参数的占位符保持原样，{{ 和 }} 输出为 { 和 }
+func format(text string, params Params) string {
+	if !strings.ContainsAny(text, "{}") {
+		return text
+	}
+	var sb strings.Builder
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if (c == '{' || c == '}') && i+1 < len(text) && text[i+1] == c {
+			sb.WriteByte(c)
+			i++
+			continue
+		}
+		if c == '{' {
+			if end := strings.IndexByte(text[i:], '}'); end > 0 {
+				name := text[i+1 : i+end]
+				if v, ok := params[name]; ok {
+					fmt.Fprint(&sb, v)
+					i += end
+					continue
+				}
+			}
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
@@ -0,0 +1,67 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	testify "github.com/stretchr/testify/assert"
+)
+
+func TestBundle(t *testing.T) {
+	assert := testify.New(t)
+
+	b := NewBundle("zh-CN")
+	assert.Nil(b.LoadFS(os.DirFS("testdata"), "."))
+	b.AddPlural("ru", "cart.items", map[string]string{"one": "{count} товар", "few": "{count} товара", "many": "{count} товаров", "other": "{count} товара"})
+	assert.Equal([]string{"en", "ru", "zh-CN"}, b.Locales())
+
+	assert.Equal("订单 42 不存在", b.T("zh-CN", "order.not_found", Params{"id": 42}))
+	assert.Equal("Order 42 not found", b.T("en-US", "order.not_found", Params{"id": 42}))
+	// 找不到的语言使用默认语言，找不到的key返回key
+	assert.Equal("购物车是空的", b.T("fr", "cart.empty"))
+	assert.Equal("missing.key", b.T("en", "missing.key"))
+	assert.Equal("{x} {{y}}", format("{x} {{{{y}}}}", nil))
+
+	assert.Equal("No items", b.N("en", "cart.items", 0))
+	assert.Equal("1 item", b.N("en", "cart.items", 1))
+	assert.Equal("5 items", b.N("en", "cart.items", 5))
+	assert.Equal("1 件商品", b.N("zh-CN", "cart.items", 1))
+	assert.Equal("21 товар", b.N("ru", "cart.items", 21))
+	assert.Equal("3 товара", b.N("ru", "cart.items", 3))
+	assert.Equal("11 товаров", b.N("ru", "cart.items", 11))
+}
+
+func TestDetect(t *testing.T) {
+	assert := testify.New(t)
+
+	b := NewBundle("zh-CN")
+	b.Add("en", "hello", "hello")
+	b.Add("zh-CN", "hello", "你好")
+	b.Add("ja", "hello", "こんにちは")
+
+	assert.Equal([]string{"fr-CH", "en", "de"}, ParseAcceptLanguage("fr-CH, de;q=0.7, *;q=0.5, en;q=0.9, it;q=0"))
+
+	detect := func(target, accept, cookie string) string {
+		r := httptest.NewRequest(http.MethodGet, target, nil)
+		if accept != "" {
+			r.Header.Set("Accept-Language", accept)
+		}
+		if cookie != "" {
+			r.AddCookie(&http.Cookie{Name: "lang", Value: cookie})
+		}
+		return b.Detect(r, DetectConfig{})
+	}
+	assert.Equal("en", detect("/", "fr-CH, en-GB;q=0.8", ""))
+	assert.Equal("zh-CN", detect("/", "zh-TW", ""))
+	assert.Equal("zh-CN", detect("/", "fr", ""))
+	assert.Equal("ja", detect("/", "en", "ja"))
+	assert.Equal("en", detect("/?lang=en_us", "zh", "ja"))
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r = r.WithContext(ContextWithLocale(r.Context(), "ja"))
+	text, ok := b.Translator(DetectConfig{})(r, "hello", nil)
+	assert.True(ok)
+	assert.Equal("こんにちは", text)
+}
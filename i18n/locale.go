@@ -0,0 +1,161 @@
+package i18n
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultQueryParam = "lang"
+	defaultCookieName = "lang"
+)
+
+// Canonical 规范化语言标签，如 zh_cn、ZH-cn 规范为 zh-CN，zh-hans 规范为 zh-Hans
+func Canonical(locale string) string {
+	parts := strings.FieldsFunc(strings.TrimSpace(locale), func(r rune) bool { return r == '-' || r == '_' })
+	for i, p := range parts {
+		switch {
+		case i == 0:
+			parts[i] = strings.ToLower(p)
+		case len(p) == 4:
+			// 书写系统，如 Hans
+			parts[i] = strings.ToUpper(p[:1]) + strings.ToLower(p[1:])
+		case len(p) == 2:
+			// 地区，如 CN
+			parts[i] = strings.ToUpper(p)
+		default:
+			parts[i] = strings.ToLower(p)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// Base 基础语言，如 zh-CN 的基础语言为 zh
+func Base(locale string) string {
+	if i := strings.IndexByte(locale, '-'); i > 0 {
+		return locale[:i]
+	}
+	return locale
+}
+
+// ParseAcceptLanguage 解析 Accept-Language 请求头，按权重从高到低返回语言，忽略 * 和权重为0的语言
+func ParseAcceptLanguage(header string) []string {
+	type tag struct {
+		locale string
+		q      float64
+	}
+	var tags []tag
+	for item := range strings.SplitSeq(header, ",") {
+		locale, params, _ := strings.Cut(strings.TrimSpace(item), ";")
+		locale = strings.TrimSpace(locale)
+		if locale == "" || locale == "*" {
+			continue
+		}
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			k, v, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.TrimSpace(k) == "q" {
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					q = f
+				}
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, tag{locale: Canonical(locale), q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	list := make([]string, 0, len(tags))
+	for _, t := range tags {
+		list = append(list, t.locale)
+	}
+	return list
+}
+
+// Match 从候选语言中选择已加载的语言，依次尝试完整语言和基础语言，
+// 基础语言也可以匹配相同基础语言的其他地区，如 zh 匹配 zh-CN；都不匹配时返回默认语言
+func (b *Bundle) Match(candidates ...string) string {
+	supported := b.Locales()
+	for _, c := range candidates {
+		c = Canonical(c)
+		if c == "" {
+			continue
+		}
+		if slices.Contains(supported, c) {
+			return c
+		}
+		base := Base(c)
+		if slices.Contains(supported, base) {
+			return base
+		}
+		for _, s := range supported {
+			if Base(s) == base {
+				return s
+			}
+		}
+	}
+	return b.fallback
+}
+
+type DetectConfig struct {
+	// QueryParam 指定语言的query参数，默认 lang，为 - 时不使用
+	QueryParam string
+	// CookieName 指定语言的cookie，默认 lang，为 - 时不使用
+	CookieName string
+}
+
+// Detect 按 query参数、cookie、Accept-Language 的顺序检测请求的语言，返回已加载的语言
+func (b *Bundle) Detect(r *http.Request, conf DetectConfig) string {
+	if locale, ok := LocaleFromContext(r.Context()); ok {
+		return locale
+	}
+	if conf.QueryParam == "" {
+		conf.QueryParam = defaultQueryParam
+	}
+	if conf.CookieName == "" {
+		conf.CookieName = defaultCookieName
+	}
+	var candidates []string
+	if conf.QueryParam != "-" && r.URL != nil {
+		if v := r.URL.Query().Get(conf.QueryParam); v != "" {
+			candidates = append(candidates, v)
+		}
+	}
+	if conf.CookieName != "-" {
+		if c, err := r.Cookie(conf.CookieName); err == nil && c.Value != "" {
+			candidates = append(candidates, c.Value)
+		}
+	}
+	candidates = append(candidates, ParseAcceptLanguage(r.Header.Get("Accept-Language"))...)
+	return b.Match(candidates...)
+}
+
+// FromRequest 创建请求语言的翻译器
+func (b *Bundle) FromRequest(r *http.Request, conf DetectConfig) *Localizer {
+	return b.Localizer(b.Detect(r, conf))
+}
+
+// Translator 返回按请求语言翻译消息的函数，用于 server.Server.Translator
+func (b *Bundle) Translator(conf DetectConfig) func(r *http.Request, key string, params map[string]any) (string, bool) {
+	return func(r *http.Request, key string, params map[string]any) (string, bool) {
+		return b.FromRequest(r, conf).Lookup(key, params)
+	}
+}
+
+type localeKey struct{}
+
+// ContextWithLocale 将语言存入ctx，之后 Detect 直接使用该语言，如用户设置中保存的语言
+func ContextWithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, Canonical(locale))
+}
+
+// LocaleFromContext 获取ctx中的语言
+func LocaleFromContext(ctx context.Context) (string, bool) {
+	locale, ok := ctx.Value(localeKey{}).(string)
+	return locale, ok
+}
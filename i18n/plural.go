@@ -0,0 +1,162 @@
+package i18n
+
+import (
+	"math"
+	"strconv"
+	"sync"
+)
+
+// 复数类别，见 CLDR Language Plural Rules
+const (
+	PluralZero  = "zero"
+	PluralOne   = "one"
+	PluralTwo   = "two"
+	PluralFew   = "few"
+	PluralMany  = "many"
+	PluralOther = "other"
+)
+
+var pluralCategories = []string{PluralZero, PluralOne, PluralTwo, PluralFew, PluralMany, PluralOther}
+
+// PluralRule 根据数量返回复数类别
+type PluralRule func(n int) string
+
+var (
+	pluralMu    sync.RWMutex
+	pluralRules = map[string]PluralRule{
+		// 中文、日文、韩文等没有复数形式
+		"zh": pluralOther, "ja": pluralOther, "ko": pluralOther, "vi": pluralOther, "th": pluralOther, "id": pluralOther,
+		"fr": pluralFrench, "pt": pluralFrench,
+		"ru": pluralSlavic, "uk": pluralSlavic, "be": pluralSlavic,
+		"pl": pluralPolish,
+		"ar": pluralArabic,
+	}
+)
+
+// RegisterPluralRule 注册语言的复数规则，language 为基础语言，如 en；未注册的语言使用英文规则
+func RegisterPluralRule(language string, rule PluralRule) {
+	pluralMu.Lock()
+	defer pluralMu.Unlock()
+	pluralRules[Base(Canonical(language))] = rule
+}
+
+func pluralRule(locale string) PluralRule {
+	pluralMu.RLock()
+	defer pluralMu.RUnlock()
+	if rule, ok := pluralRules[Base(locale)]; ok {
+		return rule
+	}
+	return pluralEnglish
+}
+
+// form 选择复数形式，count 为0且有 zero 形式时优先使用，找不到类别时使用 other
+func (m *message) form(locale string, count any) string {
+	n, ok := toInt(count)
+	if !ok {
+		return m.plural[PluralOther]
+	}
+	if n == 0 {
+		if text, ok := m.plural[PluralZero]; ok {
+			return text
+		}
+	}
+	if text, ok := m.plural[pluralRule(locale)(n)]; ok {
+		return text
+	}
+	return m.plural[PluralOther]
+}
+
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int8:
+		return int(n), true
+	case int16:
+		return int(n), true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case uint:
+		return int(n), true
+	case uint8:
+		return int(n), true
+	case uint16:
+		return int(n), true
+	case uint32:
+		return int(n), true
+	case uint64:
+		return int(min(n, math.MaxInt)), true
+	case float64:
+		return int(n), true
+	case string:
+		i, err := strconv.Atoi(n)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+func pluralOther(int) string {
+	return PluralOther
+}
+
+func pluralEnglish(n int) string {
+	if n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func pluralFrench(n int) string {
+	if n == 0 || n == 1 {
+		return PluralOne
+	}
+	return PluralOther
+}
+
+func pluralSlavic(n int) string {
+	n = abs(n)
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return PluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+func pluralPolish(n int) string {
+	n = abs(n)
+	switch {
+	case n == 1:
+		return PluralOne
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return PluralFew
+	}
+	return PluralMany
+}
+
+func pluralArabic(n int) string {
+	n = abs(n)
+	switch {
+	case n == 0:
+		return PluralZero
+	case n == 1:
+		return PluralOne
+	case n == 2:
+		return PluralTwo
+	case n%100 >= 3 && n%100 <= 10:
+		return PluralFew
+	case n%100 >= 11:
+		return PluralMany
+	}
+	return PluralOther
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"sync"
 
+	"github.com/lazygo/lazygo/health"
 	"github.com/lazygo/lazygo/internal"
 )
 
@@ -67,7 +68,12 @@ func (m *Manager) init(conf []Config, defaultName string) error {
 		if err != nil {
 			return err
 		}
+		lock = withMetrics(item.Name, withTrace(item.Name, lock))
 		m.Store(item.Name, lock)
+		// 自定义适配器实现 health.Pinger 时注册检查，内置的redis适配器由redis组件注册
+		if p, ok := lock.(health.Pinger); ok {
+			health.Register("locker/"+item.Name, p.Ping)
+		}
 
 		if defaultName == item.Name {
 			m.defaultName = defaultName
@@ -0,0 +1,69 @@
+package locker
+
+import (
+	"context"
+	"time"
+
+	"github.com/lazygo/lazygo/health"
+	"github.com/lazygo/lazygo/metrics"
+)
+
+var (
+	lockWait = metrics.NewHistogramVec("lazygo_locker_wait_seconds",
+		"Time spent waiting to acquire a lock in seconds.", nil, "locker")
+	lockAcquire = metrics.NewCounterVec("lazygo_locker_acquire_total",
+		"Total number of lock attempts by result.", "locker", "result")
+)
+
+// instrumented 记录获取锁的等待时间和结果
+type instrumented struct {
+	Locker
+	name string
+}
+
+// instrumentedPinger 被包装的锁实现 health.Pinger 时转发 Ping
+type instrumentedPinger struct {
+	*instrumented
+	pinger health.Pinger
+}
+
+func (l *instrumentedPinger) Ping(ctx context.Context) error {
+	return l.pinger.Ping(ctx)
+}
+
+func withMetrics(name string, l Locker) Locker {
+	il := &instrumented{Locker: l, name: name}
+	if p, ok := l.(health.Pinger); ok {
+		return &instrumentedPinger{instrumented: il, pinger: p}
+	}
+	return il
+}
+
+func (l *instrumented) result(ok bool, err error) {
+	result := "acquired"
+	if err != nil {
+		result = "error"
+	} else if !ok {
+		result = "busy"
+	}
+	lockAcquire.With(l.name, result).Inc()
+}
+
+func (l *instrumented) Lock(ctx context.Context, resource string, ttl uint64) (Releaser, error) {
+	start := time.Now()
+	r, err := l.Locker.Lock(ctx, resource, ttl)
+	lockWait.With(l.name).Observe(time.Since(start).Seconds())
+	l.result(err == nil, err)
+	return r, err
+}
+
+func (l *instrumented) TryLock(resource string, ttl uint64) (Releaser, bool, error) {
+	r, ok, err := l.Locker.TryLock(resource, ttl)
+	l.result(ok, err)
+	return r, ok, err
+}
+
+// LockFunc 不记录指标，适配器根据fn的函数名生成资源标识，不能包装fn
+func (l *instrumented) LockFunc(ctx context.Context, ttl uint64, fn func() any) (any, error) {
+	return l.Locker.LockFunc(ctx, ttl, fn)
+}
@@ -0,0 +1,48 @@
+package locker
+
+import (
+	"context"
+
+	"github.com/lazygo/lazygo/health"
+	"github.com/lazygo/lazygo/trace"
+)
+
+// traced ctx 中有链路信息时为获取锁创建子span
+type traced struct {
+	Locker
+	name string
+}
+
+// tracedPinger 被包装的锁实现 health.Pinger 时转发 Ping
+type tracedPinger struct {
+	*traced
+	pinger health.Pinger
+}
+
+func (l *tracedPinger) Ping(ctx context.Context) error {
+	return l.pinger.Ping(ctx)
+}
+
+func withTrace(name string, l Locker) Locker {
+	tl := &traced{Locker: l, name: name}
+	if p, ok := l.(health.Pinger); ok {
+		return &tracedPinger{traced: tl, pinger: p}
+	}
+	return tl
+}
+
+func (l *traced) Lock(ctx context.Context, resource string, ttl uint64) (Releaser, error) {
+	_, span := trace.StartChild(ctx, trace.KindClient, "lock "+l.name)
+	span.SetAttr("lock.resource", resource)
+	r, err := l.Locker.Lock(ctx, resource, ttl)
+	span.SetError(err).End()
+	return r, err
+}
+
+// LockFunc span 包含等待锁和执行fn的时间
+func (l *traced) LockFunc(ctx context.Context, ttl uint64, fn func() any) (any, error) {
+	ctx, span := trace.StartChild(ctx, trace.KindInternal, "lock_func "+l.name)
+	ret, err := l.Locker.LockFunc(ctx, ttl, fn)
+	span.SetError(err).End()
+	return ret, err
+}
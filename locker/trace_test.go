@@ -0,0 +1,32 @@
+package locker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lazygo/lazygo/health"
+)
+
+type pingLocker struct {
+	Locker
+	pings int
+}
+
+func (l *pingLocker) Ping(ctx context.Context) error {
+	l.pings++
+	return nil
+}
+
+func TestWrapForwardPing(t *testing.T) {
+	pl := &pingLocker{}
+	p, ok := withMetrics("ping", withTrace("ping", pl)).(health.Pinger)
+	if !ok {
+		t.Fatal("wrapped locker hides health.Pinger")
+	}
+	if err := p.Ping(context.Background()); err != nil || pl.pings != 1 {
+		t.Errorf("Ping() = %v, pings = %d", err, pl.pings)
+	}
+	if _, ok := withMetrics("plain", withTrace("plain", &redisAdapter{})).(health.Pinger); ok {
+		t.Error("wrapped locker should not add Ping")
+	}
+}
@@ -1,11 +1,13 @@
 package memcache
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
 	"github.com/bradfitz/gomemcache/memcache"
+	"github.com/lazygo/lazygo/health"
 )
 
 type ServerConfig struct {
@@ -36,6 +38,10 @@ func (m *Manager) init(conf []Config) error {
 			return err
 		}
 		m.Store(item.Name, newMemcache(item.Name, mc))
+		// Ping 向所有服务器发送version命令
+		health.Register("memcache/"+item.Name, func(context.Context) error {
+			return mc.Ping()
+		})
 	}
 	return nil
 }
@@ -5,4 +5,5 @@ import "errors"
 var (
 	ErrLRUCacheNotExists = errors.New("指定LRUCache不存在，或未初始化")
 	ErrSizeOverflow      = errors.New("LRU容量溢出")
+	ErrLRUZeroCapacity   = errors.New("LRU容量为0")
 )
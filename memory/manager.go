@@ -1,7 +1,11 @@
 package memory
 
 import (
+	"context"
+	"fmt"
 	"sync"
+
+	"github.com/lazygo/lazygo/health"
 )
 
 type Config struct {
@@ -21,7 +25,23 @@ func (m *Manager) init(conf []Config) error {
 		if _, ok := m.Load(item.Name); ok {
 			continue
 		}
-		m.Store(item.Name, newLRUCache(item.Name, item.Capacity))
+		lru := newLRUCache(item.Name, item.Capacity)
+		m.Store(item.Name, lru)
+		health.Register("memory/"+item.Name, func(context.Context) error {
+			return checkCapacity(lru)
+		})
+	}
+	return nil
+}
+
+// checkCapacity 容量为0时无法缓存数据，使用量超过容量时淘汰异常
+func checkCapacity(lru LRU) error {
+	capacity := lru.Capacity()
+	if capacity == 0 {
+		return ErrLRUZeroCapacity
+	}
+	if size := lru.Size(); size > capacity {
+		return fmt.Errorf("%w，使用量%d超过容量%d", ErrSizeOverflow, size, capacity)
 	}
 	return nil
 }
@@ -0,0 +1,430 @@
+package metrics
+
+// 指标采集，输出为 Prometheus 文本格式，不依赖第三方库
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// DefBuckets 默认的耗时分布（秒）
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// SizeBuckets 默认的大小分布（字节）
+var SizeBuckets = []float64{256, 1024, 4096, 16384, 65536, 262144, 1048576, 4194304}
+
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type collector interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry 指标注册表
+type Registry struct {
+	mu         sync.RWMutex
+	collectors map[string]collector
+}
+
+// NewRegistry 创建指标注册表
+func NewRegistry() *Registry {
+	return &Registry{collectors: make(map[string]collector)}
+}
+
+// Default 默认注册表，框架内置的指标注册在此
+var Default = NewRegistry()
+
+// register 注册指标，同名指标已存在时返回已注册的指标，类型不一致时panic
+func register[T collector](r *Registry, c T) T {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if exist, ok := r.collectors[c.name()]; ok {
+		if same, ok := exist.(T); ok {
+			return same
+		}
+		panic("metrics: " + c.name() + " already registered with different type")
+	}
+	r.collectors[c.name()] = c
+	return c
+}
+
+// Write 按 Prometheus 文本格式输出所有指标
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.RLock()
+	list := make([]collector, 0, len(r.collectors))
+	for _, c := range r.collectors {
+		list = append(list, c)
+	}
+	r.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].name() < list[j].name() })
+
+	bw := bufio.NewWriter(w)
+	for _, c := range list {
+		c.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler 输出指标的 http.Handler
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		_ = r.Write(w)
+	})
+}
+
+// Handler 输出默认注册表指标的 http.Handler
+func Handler() http.Handler {
+	return Default.Handler()
+}
+
+// desc 指标名称、说明和标签
+type desc struct {
+	fqName string
+	help   string
+	labels []string
+}
+
+func (d *desc) name() string {
+	return d.fqName
+}
+
+func (d *desc) header(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.fqName, escapeHelp(d.help), d.fqName, typ)
+}
+
+// vec 按标签值保存指标
+type vec[T any] struct {
+	desc
+	mu      sync.RWMutex
+	metrics map[string]*entry[T]
+	newFn   func() *T
+}
+
+type entry[T any] struct {
+	values []string
+	metric *T
+}
+
+func (v *vec[T]) with(values []string) *T {
+	if len(values) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", v.fqName, len(v.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	v.mu.RLock()
+	e, ok := v.metrics[key]
+	v.mu.RUnlock()
+	if ok {
+		return e.metric
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if e, ok := v.metrics[key]; ok {
+		return e.metric
+	}
+	e = &entry[T]{values: slices.Clone(values), metric: v.newFn()}
+	v.metrics[key] = e
+	return e.metric
+}
+
+// sorted 按标签值排序，输出稳定
+func (v *vec[T]) sorted() []*entry[T] {
+	v.mu.RLock()
+	list := make([]*entry[T], 0, len(v.metrics))
+	for _, e := range v.metrics {
+		list = append(list, e)
+	}
+	v.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return slices.Compare(list[i].values, list[j].values) < 0
+	})
+	return list
+}
+
+// Counter 只增不减的计数器
+type Counter struct {
+	bits atomic.Uint64
+}
+
+// Inc 加1
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add 增加v，v不能为负数
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counter cannot decrease")
+	}
+	addFloat(&c.bits, v)
+}
+
+// Value 当前值
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(c.bits.Load())
+}
+
+// CounterVec 带标签的计数器
+type CounterVec struct {
+	vec[Counter]
+}
+
+// NewCounterVec 在默认注册表创建计数器
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	return Default.NewCounterVec(name, help, labels...)
+}
+
+// NewCounterVec 创建计数器，同名指标已存在时返回已注册的计数器
+func (r *Registry) NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{vec[Counter]{
+		desc:    desc{fqName: name, help: help, labels: labels},
+		metrics: make(map[string]*entry[Counter]),
+		newFn:   func() *Counter { return &Counter{} },
+	}}
+	return register(r, c)
+}
+
+// With 获取标签值对应的计数器
+func (v *CounterVec) With(values ...string) *Counter {
+	return v.with(values)
+}
+
+func (v *CounterVec) write(w *bufio.Writer) {
+	v.header(w, "counter")
+	for _, e := range v.sorted() {
+		writeSample(w, v.fqName, v.labels, e.values, "", "", e.metric.Value())
+	}
+}
+
+// Gauge 可增可减的值
+type Gauge struct {
+	bits atomic.Uint64
+}
+
+// Set 设置为v
+func (g *Gauge) Set(v float64) {
+	g.bits.Store(math.Float64bits(v))
+}
+
+// Add 增加v，v可以为负数
+func (g *Gauge) Add(v float64) {
+	addFloat(&g.bits, v)
+}
+
+// Inc 加1
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+// Dec 减1
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+// Value 当前值
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(g.bits.Load())
+}
+
+// GaugeVec 带标签的值
+type GaugeVec struct {
+	vec[Gauge]
+}
+
+// NewGaugeVec 在默认注册表创建Gauge
+func NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	return Default.NewGaugeVec(name, help, labels...)
+}
+
+// NewGaugeVec 创建Gauge，同名指标已存在时返回已注册的Gauge
+func (r *Registry) NewGaugeVec(name, help string, labels ...string) *GaugeVec {
+	g := &GaugeVec{vec[Gauge]{
+		desc:    desc{fqName: name, help: help, labels: labels},
+		metrics: make(map[string]*entry[Gauge]),
+		newFn:   func() *Gauge { return &Gauge{} },
+	}}
+	return register(r, g)
+}
+
+// With 获取标签值对应的Gauge
+func (v *GaugeVec) With(values ...string) *Gauge {
+	return v.with(values)
+}
+
+func (v *GaugeVec) write(w *bufio.Writer) {
+	v.header(w, "gauge")
+	for _, e := range v.sorted() {
+		writeSample(w, v.fqName, v.labels, e.values, "", "", e.metric.Value())
+	}
+}
+
+// GaugeFunc 采集时调用函数获取值，如连接池状态
+type GaugeFunc struct {
+	desc
+	typ string
+	fn  func(observe func(value float64, labelValues ...string))
+}
+
+// NewGaugeFunc 在默认注册表创建 GaugeFunc
+func NewGaugeFunc(name, help string, labels []string, fn func(observe func(value float64, labelValues ...string))) *GaugeFunc {
+	return Default.NewGaugeFunc(name, help, labels, fn)
+}
+
+// NewGaugeFunc 创建 GaugeFunc，采集时调用fn，fn通过observe输出每组标签的值
+func (r *Registry) NewGaugeFunc(name, help string, labels []string, fn func(observe func(value float64, labelValues ...string))) *GaugeFunc {
+	return register(r, &GaugeFunc{desc: desc{fqName: name, help: help, labels: labels}, typ: "gauge", fn: fn})
+}
+
+// NewCounterFunc 在默认注册表创建采集时获取值的计数器，如连接池累计等待次数
+func NewCounterFunc(name, help string, labels []string, fn func(observe func(value float64, labelValues ...string))) *GaugeFunc {
+	return Default.NewCounterFunc(name, help, labels, fn)
+}
+
+// NewCounterFunc 创建采集时获取值的计数器
+func (r *Registry) NewCounterFunc(name, help string, labels []string, fn func(observe func(value float64, labelValues ...string))) *GaugeFunc {
+	return register(r, &GaugeFunc{desc: desc{fqName: name, help: help, labels: labels}, typ: "counter", fn: fn})
+}
+
+func (g *GaugeFunc) write(w *bufio.Writer) {
+	g.header(w, g.typ)
+	g.fn(func(value float64, labelValues ...string) {
+		if len(labelValues) != len(g.labels) {
+			return
+		}
+		writeSample(w, g.fqName, g.labels, labelValues, "", "", value)
+	})
+}
+
+// Histogram 分布统计
+type Histogram struct {
+	upper  []float64
+	counts []atomic.Uint64
+	count  atomic.Uint64
+	sum    atomic.Uint64
+}
+
+// Observe 记录一个值
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upper, v)
+	if i < len(h.counts) {
+		h.counts[i].Add(1)
+	}
+	h.count.Add(1)
+	addFloat(&h.sum, v)
+}
+
+// HistogramVec 带标签的分布统计
+type HistogramVec struct {
+	vec[Histogram]
+	buckets []float64
+}
+
+// NewHistogramVec 在默认注册表创建分布统计
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	return Default.NewHistogramVec(name, help, buckets, labels...)
+}
+
+// NewHistogramVec 创建分布统计，buckets 为升序的上界，为空时使用 DefBuckets
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	h := &HistogramVec{buckets: buckets}
+	h.vec = vec[Histogram]{
+		desc:    desc{fqName: name, help: help, labels: labels},
+		metrics: make(map[string]*entry[Histogram]),
+		newFn: func() *Histogram {
+			return &Histogram{upper: buckets, counts: make([]atomic.Uint64, len(buckets))}
+		},
+	}
+	return register(r, h)
+}
+
+// With 获取标签值对应的分布统计
+func (v *HistogramVec) With(values ...string) *Histogram {
+	return v.with(values)
+}
+
+func (v *HistogramVec) write(w *bufio.Writer) {
+	v.header(w, "histogram")
+	for _, e := range v.sorted() {
+		h := e.metric
+		var cumulative uint64
+		for i, upper := range h.upper {
+			cumulative += h.counts[i].Load()
+			writeSample(w, v.fqName+"_bucket", v.labels, e.values, "le", formatFloat(upper), float64(cumulative))
+		}
+		count := h.count.Load()
+		writeSample(w, v.fqName+"_bucket", v.labels, e.values, "le", "+Inf", float64(count))
+		writeSample(w, v.fqName+"_sum", v.labels, e.values, "", "", math.Float64frombits(h.sum.Load()))
+		writeSample(w, v.fqName+"_count", v.labels, e.values, "", "", float64(count))
+	}
+}
+
+func addFloat(bits *atomic.Uint64, v float64) {
+	for {
+		old := bits.Load()
+		if bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+v)) {
+			return
+		}
+	}
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, extraLabel, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label + `="` + escapeLabel(values[i]) + `"`)
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraLabel + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestExposition(t *testing.T) {
+	r := NewRegistry()
+	c := r.NewCounterVec("test_requests_total", "Total requests.", "route", "status")
+	c.With("/user/:id", "200").Inc()
+	c.With("/user/:id", "200").Add(2)
+	c.With(`/a"b`, "500").Inc()
+	if r.NewCounterVec("test_requests_total", "Total requests.", "route", "status") != c {
+		t.Fatal("register should return existing metric")
+	}
+
+	g := r.NewGaugeVec("test_inflight", "In flight.")
+	g.With().Inc()
+	g.With().Inc()
+	g.With().Dec()
+
+	h := r.NewHistogramVec("test_duration_seconds", "Duration.", []float64{0.1, 1}, "route")
+	h.With("/").Observe(0.05)
+	h.With("/").Observe(0.5)
+	h.With("/").Observe(2)
+
+	r.NewGaugeFunc("test_pool_open", "Open connections.", []string{"db"}, func(observe func(float64, ...string)) {
+		observe(3, "main")
+	})
+
+	w := httptest.NewRecorder()
+	r.Handler().ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/plain; version=0.0.4") {
+		t.Fatalf("content type %q", ct)
+	}
+	body := w.Body.String()
+	for _, line := range []string{
+		"# TYPE test_requests_total counter",
+		`test_requests_total{route="/a\"b",status="500"} 1`,
+		`test_requests_total{route="/user/:id",status="200"} 3`,
+		"test_inflight 1",
+		"# TYPE test_duration_seconds histogram",
+		`test_duration_seconds_bucket{route="/",le="0.1"} 1`,
+		`test_duration_seconds_bucket{route="/",le="1"} 2`,
+		`test_duration_seconds_bucket{route="/",le="+Inf"} 3`,
+		`test_duration_seconds_sum{route="/"} 2.55`,
+		`test_duration_seconds_count{route="/"} 3`,
+		`test_pool_open{db="main"} 3`,
+	} {
+		if !strings.Contains(body, line+"\n") {
+			t.Errorf("missing %q in:\n%s", line, body)
+		}
+	}
+	if strings.Index(body, "test_duration_seconds") > strings.Index(body, "test_requests_total") {
+		t.Error("metrics should be sorted by name")
+	}
+}
@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"slices"
+
+	"github.com/lazygo/lazygo/cache"
+	"github.com/lazygo/lazygo/locker"
+	"github.com/lazygo/lazygo/server"
+)
+
+const (
+	HeaderIdempotencyKey      = "Idempotency-Key"
+	HeaderIdempotentReplayed  = "Idempotent-Replayed"
+	defaultIdempotencyTTL     = 86400
+	defaultIdempotencyLockTTL = 30
+)
+
+type IdempotencyConfig struct {
+	// Cache 存储首次请求响应的缓存实例
+	Cache cache.Cache
+	// Locker 请求处理期间持有的锁，避免相同key的请求并发执行
+	Locker locker.Locker
+	// TTL 响应保存时间（秒），默认24小时
+	TTL int64
+	// LockTTL 处理中锁的生存时间（秒），默认30秒
+	LockTTL uint64
+	// Methods 需要处理幂等的请求方法，默认 POST、PUT、PATCH、DELETE
+	Methods []string
+	// Required 为true时缺少 Idempotency-Key 头的请求返回400
+	Required bool
+	// Prefix 缓存key前缀
+	Prefix string
+	// KeyFunc 生成幂等范围key，默认为 method + 路由 + Idempotency-Key，可加入uid等区分用户
+	KeyFunc func(ctx server.Context, key string) string
+}
+
+type idempotencyRecord struct {
+	Fingerprint string      `json:"fingerprint"`
+	Status      int         `json:"status"`
+	Header      http.Header `json:"header"`
+	Body        []byte      `json:"body"`
+}
+
+// Idempotency 根据 Idempotency-Key 请求头保证请求幂等
+// 首次请求的响应会被保存，相同key的后续请求直接重放保存的响应；
+// 相同key但请求体不同时返回422，相同key的请求仍在处理中时返回409。
+// 处理返回错误或5xx的响应不会保存，客户端可以使用相同key重试
+func Idempotency(conf IdempotencyConfig) server.MiddlewareFunc {
+	if conf.Cache == nil || conf.Locker == nil {
+		panic("idempotency middleware requires cache and locker")
+	}
+	if conf.TTL <= 0 {
+		conf.TTL = defaultIdempotencyTTL
+	}
+	if conf.LockTTL == 0 {
+		conf.LockTTL = defaultIdempotencyLockTTL
+	}
+	if len(conf.Methods) == 0 {
+		conf.Methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	if conf.KeyFunc == nil {
+		conf.KeyFunc = func(ctx server.Context, key string) string {
+			return ctx.Request().Method + ":" + ctx.GetRoutePath() + ":" + key
+		}
+	}
+
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx server.Context) error {
+			req := ctx.Request()
+			if !slices.Contains(conf.Methods, req.Method) {
+				return next(ctx)
+			}
+			key := req.Header.Get(HeaderIdempotencyKey)
+			if key == "" {
+				if conf.Required {
+					return server.NewHTTPError(http.StatusBadRequest, HeaderIdempotencyKey+" header required")
+				}
+				return next(ctx)
+			}
+			if len(key) > 255 {
+				return server.NewHTTPError(http.StatusBadRequest, HeaderIdempotencyKey+" header too long")
+			}
+
+			fingerprint, err := requestFingerprint(req)
+			if err != nil {
+				return server.ErrBadRequest.SetInternal(err)
+			}
+
+			sum := sha256.Sum256([]byte(conf.KeyFunc(ctx, key)))
+			cacheKey := conf.Prefix + "idempotency:" + hex.EncodeToString(sum[:])
+
+			replayed, err := replayIdempotent(ctx, conf.Cache, cacheKey, fingerprint)
+			if err != nil || replayed {
+				return err
+			}
+
+			lock, ok, err := conf.Locker.TryLock(cacheKey+":lock", conf.LockTTL)
+			if err != nil {
+				return server.ErrInternalServerError.SetInternal(err)
+			}
+			if !ok {
+				// 相同key的请求仍在处理中
+				return server.ErrConflict
+			}
+			defer lock.Release()
+
+			// 获取锁期间首个请求可能已经完成
+			replayed, err = replayIdempotent(ctx, conf.Cache, cacheKey, fingerprint)
+			if err != nil || replayed {
+				return err
+			}
+
+			rec, restore := record(ctx)
+			err = next(ctx)
+			restore()
+			if err != nil || rec == nil {
+				return err
+			}
+			status := rec.status
+			if status == 0 {
+				status = ctx.ResponseWriter().Status
+			}
+			if status >= http.StatusInternalServerError {
+				return nil
+			}
+			item := &idempotencyRecord{
+				Fingerprint: fingerprint,
+				Status:      status,
+				Header:      ctx.ResponseWriter().Header().Clone(),
+				Body:        rec.body.Bytes(),
+			}
+			// 响应已经写出，保存失败时客户端重试会再次执行
+			_ = conf.Cache.Set(cacheKey, item, conf.TTL)
+			return nil
+		}
+	}
+}
+
+// replayIdempotent 命中已保存的响应时重放
+func replayIdempotent(ctx server.Context, c cache.Cache, key, fingerprint string) (bool, error) {
+	var item idempotencyRecord
+	ok, err := c.Get(key, &item)
+	if err != nil {
+		return false, server.ErrInternalServerError.SetInternal(err)
+	}
+	if !ok || item.Status == 0 {
+		return false, nil
+	}
+	if item.Fingerprint != fingerprint {
+		return true, server.NewHTTPError(http.StatusUnprocessableEntity, HeaderIdempotencyKey+" reused with a different request")
+	}
+	ctx.ResponseWriter().Header().Set(HeaderIdempotentReplayed, "true")
+	return true, replay(ctx, item.Status, item.Header, item.Body)
+}
+
+// requestFingerprint 计算请求体摘要，读取后恢复请求体
+func requestFingerprint(req *http.Request) (string, error) {
+	h := sha256.New()
+	io.WriteString(h, req.Method+" "+req.URL.RequestURI()+"\n")
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return "", err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		h.Write(body)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
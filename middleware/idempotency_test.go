@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lazygo/lazygo/cache"
+	"github.com/lazygo/lazygo/locker"
+	"github.com/lazygo/lazygo/memory"
+	"github.com/lazygo/lazygo/server"
+	testify "github.com/stretchr/testify/assert"
+)
+
+type testLocker struct {
+	mu   sync.Mutex
+	held map[string]bool
+}
+
+func (l *testLocker) Lock(ctx context.Context, resource string, ttl uint64) (locker.Releaser, error) {
+	r, _, err := l.TryLock(resource, ttl)
+	return r, err
+}
+
+func (l *testLocker) TryLock(resource string, ttl uint64) (locker.Releaser, bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held[resource] {
+		return nil, false, nil
+	}
+	l.held[resource] = true
+	return testReleaser(func() error {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		delete(l.held, resource)
+		return nil
+	}), true, nil
+}
+
+func (l *testLocker) LockFunc(ctx context.Context, ttl uint64, fn func() any) (any, error) {
+	return fn(), nil
+}
+
+type testReleaser func() error
+
+func (r testReleaser) Release() error { return r() }
+
+var testCacheSeq atomic.Int64
+
+// testCache 创建测试用的内存缓存，每次使用不同的实例名，避免 -count 多次运行时共享数据
+func testCache(t *testing.T, name string) cache.Cache {
+	name = fmt.Sprintf("%s_%s_%d", name, t.Name(), testCacheSeq.Add(1))
+	if err := memory.Init([]memory.Config{{Name: name, Capacity: memory.MB}}); err != nil {
+		t.Fatal(err)
+	}
+	err := cache.Init([]cache.Config{{Name: name, Adapter: "memory", Option: map[string]string{"name": name}}}, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := cache.Instance(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestIdempotency(t *testing.T) {
+	assert := testify.New(t)
+
+	calls := 0
+	s := server.New()
+	s.Use(Idempotency(IdempotencyConfig{
+		Cache:  testCache(t, "idempotency"),
+		Locker: &testLocker{held: map[string]bool{}},
+	}))
+	s.Post("/orders", func(ctx server.Context) error {
+		calls++
+		body, _ := io.ReadAll(ctx.Request().Body)
+		ctx.ResponseWriter().Header().Set("X-Order", "1")
+		return ctx.JSON(http.StatusCreated, server.Map{"calls": calls, "body": string(body)})
+	})
+
+	do := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+		if key != "" {
+			r.Header.Set(HeaderIdempotencyKey, key)
+		}
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	first := do("k1", `{"amount":1}`)
+	assert.Equal(http.StatusCreated, first.Code)
+	assert.Equal("", first.Header().Get(HeaderIdempotentReplayed))
+
+	second := do("k1", `{"amount":1}`)
+	assert.Equal(http.StatusCreated, second.Code)
+	assert.Equal("true", second.Header().Get(HeaderIdempotentReplayed))
+	assert.Equal("1", second.Header().Get("X-Order"))
+	assert.Equal(first.Body.String(), second.Body.String())
+	assert.Equal(1, calls)
+
+	mismatch := do("k1", `{"amount":2}`)
+	assert.Equal(http.StatusUnprocessableEntity, mismatch.Code)
+	assert.Equal(1, calls)
+
+	do("", `{"amount":1}`)
+	do("", `{"amount":1}`)
+	assert.Equal(3, calls)
+}
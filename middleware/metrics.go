@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lazygo/lazygo/metrics"
+	"github.com/lazygo/lazygo/server"
+)
+
+const unmatchedRoute = "<unmatched>"
+
+type MetricsConfig struct {
+	// Registry 指标注册表，默认 metrics.Default
+	Registry *metrics.Registry
+	// Buckets 请求耗时分布（秒），默认 metrics.DefBuckets
+	Buckets []float64
+	// SizeBuckets 响应大小分布（字节），默认 metrics.SizeBuckets
+	SizeBuckets []float64
+	// Skipper 返回true时不记录，如指标接口本身
+	Skipper func(ctx server.Context) bool
+}
+
+// Metrics 按路由记录请求数、耗时和响应大小
+// 路由使用 GetRoutePath 返回的路由模式（如 /user/:id），避免路径参数导致标签过多；
+// 处理返回错误时，状态码取 HTTPError 的 Code，其他错误记为500
+func Metrics(conf MetricsConfig) server.MiddlewareFunc {
+	if conf.Registry == nil {
+		conf.Registry = metrics.Default
+	}
+	if len(conf.SizeBuckets) == 0 {
+		conf.SizeBuckets = metrics.SizeBuckets
+	}
+	requests := conf.Registry.NewCounterVec("lazygo_http_requests_total",
+		"Total number of HTTP requests.", "method", "route", "status")
+	duration := conf.Registry.NewHistogramVec("lazygo_http_request_duration_seconds",
+		"HTTP request latency in seconds.", conf.Buckets, "method", "route", "status")
+	size := conf.Registry.NewHistogramVec("lazygo_http_response_size_bytes",
+		"HTTP response size in bytes.", conf.SizeBuckets, "method", "route", "status")
+
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx server.Context) error {
+			if conf.Skipper != nil && conf.Skipper(ctx) {
+				return next(ctx)
+			}
+			start := time.Now()
+			err := next(ctx)
+			elapsed := time.Since(start).Seconds()
+
+			route := ctx.GetRoutePath()
+			if route == "" {
+				route = unmatchedRoute
+			}
+			w := ctx.ResponseWriter()
+			status := w.Status
+			if err != nil {
+				status = errorStatus(err)
+			}
+			if status == 0 {
+				status = http.StatusOK
+			}
+			labels := []string{ctx.Request().Method, route, strconv.Itoa(status)}
+			requests.With(labels...).Inc()
+			duration.With(labels...).Observe(elapsed)
+			size.With(labels...).Observe(float64(w.Size))
+			return err
+		}
+	}
+}
+
+func errorStatus(err error) int {
+	var he *server.HTTPError
+	if errors.As(err, &he) {
+		if he.Internal != nil {
+			var inner *server.HTTPError
+			if errors.As(he.Internal, &inner) {
+				return inner.Code
+			}
+		}
+		return he.Code
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lazygo/lazygo/metrics"
+	"github.com/lazygo/lazygo/server"
+	testify "github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	assert := testify.New(t)
+
+	reg := metrics.NewRegistry()
+	s := server.New()
+	s.Use(Metrics(MetricsConfig{Registry: reg}))
+	s.Get("/users/:id", func(ctx server.Context) error {
+		id, _ := ctx.Param("id")
+		if id == "0" {
+			return server.ErrNotFound
+		}
+		return ctx.HTML(http.StatusOK, "user "+id)
+	})
+
+	for _, uri := range []string{"/users/1", "/users/2", "/users/0"} {
+		s.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, uri, nil))
+	}
+
+	buf := &bytes.Buffer{}
+	assert.Nil(reg.Write(buf))
+	body := buf.String()
+	assert.Contains(body, `lazygo_http_requests_total{method="GET",route="/users/:id",status="200"} 2`+"\n")
+	assert.Contains(body, `lazygo_http_requests_total{method="GET",route="/users/:id",status="404"} 1`+"\n")
+	assert.Contains(body, `lazygo_http_response_size_bytes_sum{method="GET",route="/users/:id",status="200"} 12`+"\n")
+	assert.Contains(body, `lazygo_http_request_duration_seconds_count{method="GET",route="/users/:id",status="200"} 2`+"\n")
+}
@@ -0,0 +1,98 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/lazygo/lazygo/server"
+)
+
+// responseRecorder 在写出响应的同时记录状态码和响应体
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	r.status = code
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+func (r *responseRecorder) Flush() {
+	if flusher, ok := r.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// record 替换ctx的底层writer以记录响应，返回的restore用于恢复原writer
+// 底层writer不是 http.ResponseWriter 时无法记录，返回nil
+func record(ctx server.Context) (*responseRecorder, func()) {
+	rw := ctx.ResponseWriter()
+	w, ok := rw.Writer.(http.ResponseWriter)
+	if !ok {
+		return nil, func() {}
+	}
+	rec := &responseRecorder{ResponseWriter: w}
+	rw.Writer = rec
+	return rec, func() {
+		rw.Writer = w
+	}
+}
+
+// replay 将缓存的响应写入ctx
+func replay(ctx server.Context, status int, header http.Header, body []byte) error {
+	h := ctx.ResponseWriter().Header()
+	for k, v := range header {
+		h[k] = v
+	}
+	h.Del(server.HeaderContentLength)
+	ctx.ResponseWriter().WriteHeader(status)
+	_, err := ctx.ResponseWriter().Write(body)
+	return err
+}
+
+// bufferWriter 缓冲完整响应，不直接写出
+type bufferWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferWriter) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferWriter) WriteHeader(code int) {
+	b.status = code
+}
+
+func (b *bufferWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.body.Write(p)
+}
+
+// buffer 替换ctx的底层writer以缓冲响应，返回的restore用于恢复原writer并重置提交状态，
+// 之后可以通过 replay 将缓冲的内容或其他内容写出
+func buffer(ctx server.Context) (*bufferWriter, func()) {
+	rw := ctx.ResponseWriter()
+	w := rw.Writer
+	buf := &bufferWriter{header: http.Header{}}
+	rw.Writer = buf
+	return buf, func() {
+		rw.Writer = w
+		rw.Committed = false
+		rw.Size = 0
+		rw.Status = http.StatusOK
+	}
+}
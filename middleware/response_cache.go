@@ -0,0 +1,231 @@
+package middleware
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lazygo/lazygo/cache"
+	"github.com/lazygo/lazygo/server"
+)
+
+const (
+	HeaderXCache = "X-Cache"
+
+	responseCacheTagsKey    = "response_cache_tags"
+	responseCacheVerTTL     = 7 * 86400
+	defaultResponseCacheTTL = 60
+)
+
+type ResponseCacheConfig struct {
+	// Cache 存储响应的缓存实例
+	Cache cache.Cache
+	// TTL 默认缓存时间（秒），路由未指定时使用
+	TTL int64
+	// StaleTTL 过期后继续保留的时间（秒），期间处理失败或返回5xx时使用过期的响应（stale-if-error）
+	StaleTTL int64
+	// QueryParams 参与缓存key计算的query参数，为nil时使用全部参数
+	QueryParams []string
+	// VaryHeaders 参与缓存key计算的请求头，同时写入响应的 Vary 头
+	VaryHeaders []string
+	// Prefix 缓存key前缀
+	Prefix string
+}
+
+// ResponseCache 完整响应缓存
+// 路由和标签通过版本号失效：清除时更新版本号，版本号不一致的缓存不再使用
+type ResponseCache struct {
+	conf ResponseCacheConfig
+}
+
+type responseCacheEntry struct {
+	Status   int              `json:"status"`
+	Header   http.Header      `json:"header"`
+	Body     []byte           `json:"body"`
+	Expires  int64            `json:"expires"`
+	Versions map[string]int64 `json:"versions"`
+}
+
+// NewResponseCache 创建响应缓存
+func NewResponseCache(conf ResponseCacheConfig) *ResponseCache {
+	if conf.Cache == nil {
+		panic("response cache requires cache")
+	}
+	if conf.TTL <= 0 {
+		conf.TTL = defaultResponseCacheTTL
+	}
+	for i, h := range conf.VaryHeaders {
+		conf.VaryHeaders[i] = http.CanonicalHeaderKey(h)
+	}
+	return &ResponseCache{conf: conf}
+}
+
+// CacheTags 为当前响应添加标签，可在处理函数中根据数据动态添加，如 "user:42"
+func CacheTags(ctx server.Context, tags ...string) {
+	list, _ := ctx.Value(responseCacheTagsKey).([]string)
+	ctx.WithValue(responseCacheTagsKey, append(list, tags...))
+}
+
+// Middleware 返回路由级缓存中间件
+// ttl 缓存时间（秒），为0时使用默认值
+// tags 响应的标签，可通过 PurgeTag 清除
+// 只缓存GET请求的2xx响应，响应包含 Set-Cookie 或 Cache-Control: no-store/private 时不缓存。
+// 缓存的路由响应会先完整缓冲再写出
+func (rc *ResponseCache) Middleware(ttl int64, tags ...string) server.MiddlewareFunc {
+	if ttl <= 0 {
+		ttl = rc.conf.TTL
+	}
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx server.Context) error {
+			req := ctx.Request()
+			if req.Method != http.MethodGet || strings.Contains(req.Header.Get("Cache-Control"), "no-cache") {
+				return next(ctx)
+			}
+			routeKey := rc.routeKey(req.Method, ctx.GetRoutePath())
+			key := rc.key(ctx)
+
+			var entry responseCacheEntry
+			ok, err := rc.conf.Cache.Get(key, &entry)
+			if err != nil || !ok || entry.Status == 0 || !rc.valid(&entry) {
+				ok = false
+			}
+			now := time.Now().Unix()
+			if ok && now < entry.Expires {
+				return rc.replay(ctx, "HIT", entry.Status, entry.Header, entry.Body)
+			}
+
+			buf, restore := buffer(ctx)
+			err = next(ctx)
+			restore()
+			if (err != nil || buf.status >= http.StatusInternalServerError) && ok {
+				return rc.replay(ctx, "STALE", entry.Status, entry.Header, entry.Body)
+			}
+			if err != nil {
+				return err
+			}
+			status := buf.status
+			if status == 0 {
+				status = ctx.ResponseWriter().Status
+			}
+
+			if rc.cacheable(status, buf.header) {
+				dynamicTags, _ := ctx.Value(responseCacheTagsKey).([]string)
+				versions := map[string]int64{routeKey: rc.version(routeKey)}
+				for _, tag := range append(slices.Clone(tags), dynamicTags...) {
+					tagKey := rc.tagKey(tag)
+					versions[tagKey] = rc.version(tagKey)
+				}
+				_ = rc.conf.Cache.Set(key, &responseCacheEntry{
+					Status:   status,
+					Header:   buf.header,
+					Body:     buf.body.Bytes(),
+					Expires:  now + ttl,
+					Versions: versions,
+				}, ttl+rc.conf.StaleTTL)
+			}
+			return rc.replay(ctx, "MISS", status, buf.header, buf.body.Bytes())
+		}
+	}
+}
+
+// PurgeRoute 清除路由的所有缓存
+// path 为注册路由时的路径，如 /api/article/:id
+func (rc *ResponseCache) PurgeRoute(method, path string) error {
+	return rc.bump(rc.routeKey(method, path))
+}
+
+// PurgeTag 清除带有指定标签的所有缓存
+func (rc *ResponseCache) PurgeTag(tags ...string) error {
+	for _, tag := range tags {
+		if err := rc.bump(rc.tagKey(tag)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (rc *ResponseCache) bump(key string) error {
+	return rc.conf.Cache.Set(key, time.Now().UnixNano(), responseCacheVerTTL)
+}
+
+func (rc *ResponseCache) version(key string) int64 {
+	var ver int64
+	_, _ = rc.conf.Cache.Get(key, &ver)
+	return ver
+}
+
+// valid 判断缓存的路由和标签版本是否仍然有效
+func (rc *ResponseCache) valid(entry *responseCacheEntry) bool {
+	for key, ver := range entry.Versions {
+		if rc.version(key) != ver {
+			return false
+		}
+	}
+	return true
+}
+
+func (rc *ResponseCache) cacheable(status int, header http.Header) bool {
+	if status < http.StatusOK || status >= http.StatusMultipleChoices || status == http.StatusPartialContent {
+		return false
+	}
+	if header.Get(server.HeaderSetCookie) != "" {
+		return false
+	}
+	cc := strings.ToLower(header.Get("Cache-Control"))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private")
+}
+
+// replay 写出响应，并添加 Vary 和 X-Cache 头
+func (rc *ResponseCache) replay(ctx server.Context, state string, status int, header http.Header, body []byte) error {
+	header = header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	for _, h := range rc.conf.VaryHeaders {
+		if !slices.Contains(header.Values(server.HeaderVary), h) {
+			header.Add(server.HeaderVary, h)
+		}
+	}
+	header.Set(HeaderXCache, state)
+	return replay(ctx, status, header, body)
+}
+
+func (rc *ResponseCache) routeKey(method, path string) string {
+	return rc.conf.Prefix + "response_cache:route:" + method + ":" + path
+}
+
+func (rc *ResponseCache) tagKey(tag string) string {
+	return rc.conf.Prefix + "response_cache:tag:" + tag
+}
+
+// key 由请求方法、路径、query参数和Vary请求头计算缓存key
+func (rc *ResponseCache) key(ctx server.Context) string {
+	req := ctx.Request()
+	var b strings.Builder
+	b.WriteString(req.Method)
+	b.WriteByte(' ')
+	b.WriteString(req.URL.Path)
+
+	query := req.URL.Query()
+	if rc.conf.QueryParams != nil {
+		selected := url.Values{}
+		for _, k := range rc.conf.QueryParams {
+			if values, ok := query[k]; ok {
+				selected[k] = values
+			}
+		}
+		query = selected
+	}
+	// Encode 按key排序，参数顺序不同的请求使用相同缓存
+	b.WriteString("?" + query.Encode())
+	for _, h := range rc.conf.VaryHeaders {
+		b.WriteString("\n" + h + ":")
+		b.WriteString(strings.Join(req.Header.Values(h), ","))
+	}
+	sum := sha256.Sum256([]byte(b.String()))
+	return rc.conf.Prefix + "response_cache:" + hex.EncodeToString(sum[:])
+}
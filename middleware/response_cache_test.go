@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lazygo/lazygo/server"
+	testify "github.com/stretchr/testify/assert"
+)
+
+func TestResponseCache(t *testing.T) {
+	assert := testify.New(t)
+
+	rc := NewResponseCache(ResponseCacheConfig{
+		Cache:       testCache(t, "response_cache"),
+		StaleTTL:    60,
+		QueryParams: []string{"page"},
+		VaryHeaders: []string{"accept-language"},
+	})
+
+	calls := 0
+	fail := false
+	s := server.New()
+	s.Get("/articles/:id", func(ctx server.Context) error {
+		if fail {
+			return errors.New("db down")
+		}
+		calls++
+		id, _ := ctx.Param("id")
+		CacheTags(ctx, "article:"+id)
+		return ctx.HTML(http.StatusOK, strconv.Itoa(calls))
+	}, rc.Middleware(1, "articles"))
+
+	do := func(uri string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, uri, nil)
+		r.Header.Set("Accept-Language", "zh")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	w := do("/articles/1?page=1&t=1")
+	assert.Equal("MISS", w.Header().Get(HeaderXCache))
+	assert.Equal("Accept-Language", w.Header().Get(server.HeaderVary))
+	assert.Equal("1", w.Body.String())
+
+	// 未参与key计算的参数不影响缓存
+	w = do("/articles/1?t=2&page=1")
+	assert.Equal("HIT", w.Header().Get(HeaderXCache))
+	assert.Equal("1", w.Body.String())
+
+	w = do("/articles/1?page=2")
+	assert.Equal("MISS", w.Header().Get(HeaderXCache))
+	assert.Equal(2, calls)
+
+	assert.Nil(rc.PurgeTag("article:1"))
+	w = do("/articles/1?page=1")
+	assert.Equal("MISS", w.Header().Get(HeaderXCache))
+	assert.Equal("3", w.Body.String())
+
+	assert.Nil(rc.PurgeRoute(http.MethodGet, "/articles/:id"))
+	w = do("/articles/1?page=1")
+	assert.Equal("MISS", w.Header().Get(HeaderXCache))
+	assert.Equal("4", w.Body.String())
+
+	// 过期后处理失败时使用过期的响应
+	time.Sleep(1100 * time.Millisecond)
+	fail = true
+	w = do("/articles/1?page=1")
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Equal("STALE", w.Header().Get(HeaderXCache))
+	assert.Equal("4", w.Body.String())
+
+	w = do("/articles/2")
+	assert.Equal(http.StatusInternalServerError, w.Code)
+}
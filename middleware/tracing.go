@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/lazygo/lazygo/server"
+	"github.com/lazygo/lazygo/trace"
+)
+
+type TracingConfig struct {
+	// Skipper 返回true时不创建span，上游的链路信息仍会传递
+	Skipper func(ctx server.Context) bool
+}
+
+// Tracing 从 traceparent/tracestate 请求头解析上游链路并创建服务端span
+// span存入请求的context，处理函数中通过 trace.Start(ctx, ...) 创建子span，
+// 使用 httpclient 请求下游时自动传递链路信息；span名称为 method + 路由模式
+func Tracing(conf TracingConfig) server.MiddlewareFunc {
+	return func(next server.HandlerFunc) server.HandlerFunc {
+		return func(ctx server.Context) error {
+			req := ctx.Request()
+			parent := trace.Extract(req.Context(), req.Header)
+			if conf.Skipper != nil && conf.Skipper(ctx) {
+				ctx.SetRequest(req.WithContext(parent))
+				return next(ctx)
+			}
+			spanCtx, span := trace.Start(parent, trace.KindServer, req.Method)
+			defer span.End()
+			ctx.SetRequest(req.WithContext(spanCtx))
+
+			err := next(ctx)
+
+			route := ctx.GetRoutePath()
+			if route != "" {
+				span.SetName(req.Method + " " + route)
+				span.SetAttr("http.route", route)
+			}
+			status := ctx.ResponseWriter().Status
+			if err != nil {
+				status = errorStatus(err)
+			}
+			if status == 0 {
+				status = http.StatusOK
+			}
+			span.SetAttr("http.request.method", req.Method)
+			span.SetAttr("url.path", req.URL.Path)
+			span.SetAttr("client.address", ctx.RealIP())
+			span.SetAttr("http.response.status_code", status)
+			if status >= http.StatusInternalServerError {
+				span.SetStatus(trace.StatusError, http.StatusText(status))
+			}
+			if err != nil {
+				span.SetError(err)
+			}
+			return err
+		}
+	}
+}
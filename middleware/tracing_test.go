@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lazygo/lazygo/server"
+	"github.com/lazygo/lazygo/trace"
+	testify "github.com/stretchr/testify/assert"
+)
+
+func TestTracing(t *testing.T) {
+	assert := testify.New(t)
+
+	buf := &bytes.Buffer{}
+	trace.Init(trace.Config{ServiceName: "api", Exporter: trace.NewStdoutExporter(buf)})
+	defer trace.Shutdown(context.Background())
+
+	var downstream string
+	s := server.New()
+	s.Use(Tracing(TracingConfig{}))
+	s.Get("/users/:id", func(ctx server.Context) error {
+		header := http.Header{}
+		trace.Inject(ctx, header)
+		downstream = header.Get(trace.HeaderTraceparent)
+		return ctx.HTML(http.StatusOK, "ok")
+	})
+
+	r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	r.Header.Set(trace.HeaderTraceparent, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	s.ServeHTTP(httptest.NewRecorder(), r)
+	trace.Flush(context.Background())
+
+	var span map[string]any
+	line, err := bufio.NewReader(buf).ReadBytes('\n')
+	assert.Nil(err)
+	assert.Nil(json.Unmarshal(line, &span))
+	assert.Equal("GET /users/:id", span["name"])
+	assert.Equal("server", span["kind"])
+	assert.Equal("4bf92f3577b34da6a3ce929d0e0e4736", span["trace_id"])
+	assert.Equal("00f067aa0ba902b7", span["parent_span_id"])
+	assert.Equal(float64(200), span["attributes"].(map[string]any)["http.response.status_code"])
+	// 下游请求携带当前服务端span
+	assert.Equal("00-4bf92f3577b34da6a3ce929d0e0e4736-"+span["span_id"].(string)+"-01", downstream)
+}
@@ -1,9 +1,11 @@
 package redis
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
+	"github.com/lazygo/lazygo/health"
 	goredis "github.com/redis/go-redis/v9"
 )
 
@@ -34,6 +36,9 @@ func (m *Manager) init(conf []Config) error {
 			return err
 		}
 		m.Store(item.Name, client)
+		health.Register("redis/"+item.Name, func(ctx context.Context) error {
+			return client.Ping(ctx).Err()
+		})
 	}
 	return nil
 }
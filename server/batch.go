@@ -0,0 +1,209 @@
+package server
+
+import (
+	"bytes"
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrBatchTooLarge = errors.New("batch size exceeds limit")
+	ErrBatchNested   = errors.New("nested batch request not allowed")
+	ErrBatchURI      = errors.New("uri must be an absolute path")
+)
+
+const (
+	defaultBatchMaxItems    = 20
+	defaultBatchMaxBodySize = 1 << 20 // 1 MB
+)
+
+// batchMethods 子请求允许的方法
+var batchMethods = []string{
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodPatch,
+	http.MethodDelete,
+	http.MethodOptions,
+}
+
+type batchCtxKey struct{}
+
+type (
+	// BatchConfig 批量请求配置
+	BatchConfig struct {
+		// MaxItems 单次批量请求最多包含的子请求数，默认20
+		MaxItems int
+		// Concurrency 子请求并发数，小于等于1时顺序执行
+		Concurrency int
+		// MaxBodySize 批量请求体最大字节数，默认1MB
+		MaxBodySize int64
+	}
+
+	// BatchRequest 批量请求中的单个子请求
+	BatchRequest struct {
+		Method string          `json:"method"`
+		URI    string          `json:"uri"`
+		Header http.Header     `json:"header,omitempty"`
+		Body   json.RawMessage `json:"body,omitempty"`
+	}
+
+	// BatchResponse 子请求的响应，与请求按顺序一一对应
+	BatchResponse struct {
+		Status int             `json:"status"`
+		Header http.Header     `json:"header,omitempty"`
+		Body   json.RawMessage `json:"body,omitempty"`
+		Error  string          `json:"error,omitempty"`
+	}
+)
+
+// BatchHandler 批量请求处理器，请求体为子请求的json数组，
+// 每个子请求通过 Server.ServeHTTP 经过完整的中间件链处理，返回对应的响应数组。
+// 子请求继承批量请求的请求头（如 Authorization、Cookie），子请求中的同名头会覆盖
+func BatchHandler(conf BatchConfig) HandlerFunc {
+	if conf.MaxItems <= 0 {
+		conf.MaxItems = defaultBatchMaxItems
+	}
+	if conf.MaxBodySize <= 0 {
+		conf.MaxBodySize = defaultBatchMaxBodySize
+	}
+	return func(c Context) error {
+		if c.Value(batchCtxKey{}) != nil {
+			return ErrBadRequest.SetInternal(ErrBatchNested)
+		}
+
+		r := c.Request()
+		var items []BatchRequest
+		err := json.NewDecoder(http.MaxBytesReader(c.ResponseWriter(), r.Body, conf.MaxBodySize)).Decode(&items)
+		if err != nil {
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				return ErrStatusRequestEntityTooLarge.SetInternal(err)
+			}
+			return ErrBadRequest.SetInternal(fmt.Errorf("decode batch request fail: %w", err))
+		}
+		if len(items) > conf.MaxItems {
+			return ErrStatusRequestEntityTooLarge.SetInternal(fmt.Errorf("%w: %d > %d", ErrBatchTooLarge, len(items), conf.MaxItems))
+		}
+
+		results := make([]BatchResponse, len(items))
+		if conf.Concurrency <= 1 {
+			for i := range items {
+				results[i] = c.s().serveBatchItem(r, &items[i])
+			}
+			return c.JSON(http.StatusOK, results)
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, conf.Concurrency)
+		for i := range items {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				results[i] = c.s().serveBatchItem(r, &items[i])
+			}()
+		}
+		wg.Wait()
+		return c.JSON(http.StatusOK, results)
+	}
+}
+
+// serveBatchItem 将子请求转换为内部 http.Request 并交给 ServeHTTP 处理
+func (s *Server) serveBatchItem(parent *http.Request, item *BatchRequest) BatchResponse {
+	req, err := newBatchRequest(parent, item)
+	if err != nil {
+		return BatchResponse{Status: http.StatusBadRequest, Error: err.Error()}
+	}
+	w := &batchResponseWriter{header: http.Header{}}
+	s.ServeHTTP(w, req)
+
+	resp := BatchResponse{Status: w.status, Header: w.header}
+	if resp.Status == 0 {
+		resp.Status = http.StatusOK
+	}
+	body := bytes.TrimSpace(w.buf.Bytes())
+	if len(body) > 0 {
+		if json.Valid(body) {
+			resp.Body = body
+		} else {
+			// 非json响应作为字符串返回
+			resp.Body, _ = json.Marshal(w.buf.String())
+		}
+	}
+	return resp
+}
+
+func newBatchRequest(parent *http.Request, item *BatchRequest) (*http.Request, error) {
+	method := strings.ToUpper(item.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	if !slices.Contains(batchMethods, method) {
+		return nil, fmt.Errorf("method %s not allowed", item.Method)
+	}
+	if !strings.HasPrefix(item.URI, "/") {
+		return nil, ErrBatchURI
+	}
+
+	body := []byte(item.Body)
+	var str string
+	if len(body) > 0 && body[0] == '"' && json.Unmarshal(body, &str) == nil {
+		// 字符串类型的body按原始内容发送，如表单
+		body = []byte(str)
+	}
+
+	ctx := stdContext.WithValue(parent.Context(), batchCtxKey{}, true)
+	req, err := http.NewRequestWithContext(ctx, method, item.URI, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	req.RequestURI = item.URI
+	req.RemoteAddr = parent.RemoteAddr
+	req.TLS = parent.TLS
+	req.Host = parent.Host
+	req.Proto, req.ProtoMajor, req.ProtoMinor = parent.Proto, parent.ProtoMajor, parent.ProtoMinor
+	req.Header = parent.Header.Clone()
+	req.Header.Del(HeaderContentLength)
+	req.Header.Del(HeaderContentEncoding)
+	for k, v := range item.Header {
+		req.Header[http.CanonicalHeaderKey(k)] = v
+	}
+	if len(body) > 0 && req.Header.Get(HeaderContentType) == "" {
+		req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	}
+	return req, nil
+}
+
+type batchResponseWriter struct {
+	header http.Header
+	status int
+	buf    bytes.Buffer
+}
+
+func (w *batchResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *batchResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *batchResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.buf.Write(b)
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBatchHandler(t *testing.T) {
+	s := New()
+	s.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.ResponseWriter().Header().Set("X-Middleware", "1")
+			return next(c)
+		}
+	})
+	s.Get("/users/:id", func(c Context) error {
+		id, _ := c.Param("id")
+		return c.JSON(http.StatusOK, Map{"id": id, "auth": c.RequestHeader(HeaderAuthorization)})
+	})
+	s.Post("/echo", func(c Context) error {
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := c.Bind(&req); err != nil {
+			return err
+		}
+		return c.HTML(http.StatusCreated, "hello "+req.Name)
+	})
+	s.Post("/batch", BatchHandler(BatchConfig{MaxItems: 4, Concurrency: 2}))
+
+	do := func(body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/batch", strings.NewReader(body))
+		r.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		r.Header.Set(HeaderAuthorization, "token")
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		return w
+	}
+
+	w := do(`[
+		{"method":"GET","uri":"/users/1"},
+		{"method":"POST","uri":"/echo","body":{"name":"lazygo"}},
+		{"method":"GET","uri":"/missing"},
+		{"method":"WEBSOCKET","uri":"/users/1"},
+		{"method":"POST","uri":"/batch","body":[]}
+	]`)
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("batch size limit status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+
+	w = do(`[
+		{"method":"GET","uri":"/users/1"},
+		{"method":"POST","uri":"/echo","body":{"name":"lazygo"}},
+		{"method":"WEBSOCKET","uri":"/users/1"},
+		{"method":"POST","uri":"/batch","body":[]}
+	]`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("batch status = %d, want %d", w.Code, http.StatusOK)
+	}
+	var results []BatchResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 4 {
+		t.Fatalf("batch results = %d, want 4", len(results))
+	}
+	if results[0].Status != http.StatusOK || results[0].Header.Get("X-Middleware") != "1" {
+		t.Errorf("results[0] = %+v", results[0])
+	}
+	var user map[string]string
+	_ = json.Unmarshal(results[0].Body, &user)
+	if user["id"] != "1" || user["auth"] != "token" {
+		t.Errorf("results[0].Body = %s", results[0].Body)
+	}
+	if results[1].Status != http.StatusCreated || string(results[1].Body) != `"hello lazygo"` {
+		t.Errorf("results[1] = %d %s", results[1].Status, results[1].Body)
+	}
+	if results[2].Status != http.StatusBadRequest || results[2].Error == "" {
+		t.Errorf("results[2] = %+v", results[2])
+	}
+	if results[3].Status != http.StatusBadRequest {
+		t.Errorf("nested batch status = %d, want %d", results[3].Status, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,300 @@
+package server
+
+import (
+	stdContext "context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	clusterBroadcastChannel = "lazygo:event:broadcast"
+	clusterNodeChannel      = "lazygo:event:node:"
+	clusterOwnerTTL         = 60 * time.Second
+	clusterForwardTTL       = 60 * time.Second
+
+	clusterMsgBroadcast = "broadcast"
+	clusterMsgPublish   = "publish"
+	clusterMsgSendWhere = "send_where"
+	clusterMsgRequest   = "request"
+	clusterMsgResponse  = "response"
+)
+
+// Backplane 集群消息通道，用于在多个实例间转发Event消息，以及记录cid所在的节点
+type Backplane interface {
+	// Publish 向channel发布消息
+	Publish(ctx stdContext.Context, channel string, msg []byte) error
+	// Subscribe 订阅channel，ctx结束时取消订阅
+	Subscribe(ctx stdContext.Context, channel string, handler func(msg []byte)) error
+	// SetOwner 记录key所在的节点
+	SetOwner(ctx stdContext.Context, key, node string, ttl time.Duration) error
+	// Owner 获取key所在的节点，不存在时返回空字符串
+	Owner(ctx stdContext.Context, key string) (string, error)
+	// DelOwner 当key仍属于node时删除记录
+	DelOwner(ctx stdContext.Context, key, node string) error
+}
+
+type clusterMessage struct {
+	Type    string          `json:"type"`
+	Node    string          `json:"node"`
+	Method  string          `json:"method"`
+	Subject string          `json:"subject"`
+	CID     uint64          `json:"cid,omitempty"`
+	Room    string          `json:"room,omitempty"`
+	Key     string          `json:"key,omitempty"`
+	Value   string          `json:"value,omitempty"`
+	Data    *EventData      `json:"data"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+type cluster struct {
+	node      string
+	backplane Backplane
+	em        *EventManager
+}
+
+// EnableCluster 启用集群，Event.Broadcast 会转发到所有节点，
+// Event.Request 会转发到cid所在的节点并将响应返回给调用方，Server.Notify 会通知所有节点上的长轮询
+// node 为当前节点的唯一标识，为空时自动生成
+func (s *Server) EnableCluster(ctx stdContext.Context, node string, b Backplane) error {
+	if node == "" {
+		node = newNodeID()
+	}
+	c := &cluster{node: node, backplane: b, em: s.eventManager}
+	if err := b.Subscribe(ctx, clusterBroadcastChannel, c.receive); err != nil {
+		return err
+	}
+	if err := b.Subscribe(ctx, clusterNodeChannel+node, c.receive); err != nil {
+		return err
+	}
+	if err := b.Subscribe(ctx, clusterNotifyChannel, c.receiveNotify); err != nil {
+		return err
+	}
+	s.eventManager.cluster = c
+	return nil
+}
+
+// NodeID 当前节点标识，未启用集群时返回空字符串
+func (s *Server) NodeID() string {
+	if s.eventManager.cluster == nil {
+		return ""
+	}
+	return s.eventManager.cluster.node
+}
+
+func newNodeID() string {
+	host, _ := os.Hostname()
+	b := make([]byte, 4)
+	_, _ = rand.Read(b)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(b))
+}
+
+func ownerKey(method, subject string, cid uint64) string {
+	return "lazygo:event:owner:" + method + ":" + subject + ":" + strconv.FormatUint(cid, 10)
+}
+
+func (c *cluster) publish(ctx stdContext.Context, channel string, msg *clusterMessage) error {
+	msg.Node = c.node
+	b, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return c.backplane.Publish(ctx, channel, b)
+}
+
+// receive 处理其他节点发来的消息
+func (c *cluster) receive(b []byte) {
+	var msg clusterMessage
+	if err := json.Unmarshal(b, &msg); err != nil || msg.Data == nil {
+		return
+	}
+	if msg.Node == c.node {
+		// 忽略自己发布的广播
+		return
+	}
+	e := c.em.Get(msg.Method, msg.Subject)
+	switch msg.Type {
+	case clusterMsgBroadcast:
+		_ = e.broadcastLocal(msg.Data)
+	case clusterMsgPublish:
+		_ = e.sendLocal(e.Members(msg.Room), msg.Data)
+	case clusterMsgSendWhere:
+		_ = e.sendLocal(e.Find(msg.Key, msg.Value), msg.Data)
+	case clusterMsgRequest:
+		e.mu.RLock()
+		src, ok := e.src[msg.CID]
+		e.mu.RUnlock()
+		if !ok {
+			return
+		}
+		// 记录请求来源节点，客户端响应时转发回去
+		key := forwardKey(msg.CID, msg.Data.RID)
+		e.forwarded.Store(key, msg.Node)
+		time.AfterFunc(clusterForwardTTL, func() {
+			e.forwarded.CompareAndDelete(key, msg.Node)
+		})
+		if err := src.Send(msg.Data); err != nil {
+			e.forwarded.CompareAndDelete(key, msg.Node)
+		}
+	case clusterMsgResponse:
+		ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Second)
+		defer cancel()
+		_, _ = e.waiter.Put(ctx, strconv.FormatUint(msg.Data.RID, 10), msg.Data)
+	}
+}
+
+// forwardKey 转发请求的标识，rid只在连接内唯一，需要同时使用cid
+func forwardKey(cid, rid uint64) string {
+	return strconv.FormatUint(cid, 10) + ":" + strconv.FormatUint(rid, 10)
+}
+
+// register 记录cid所在节点，并在连接期间定时续期
+func (c *cluster) register(ctx stdContext.Context, key string) func() {
+	_ = c.backplane.SetOwner(ctx, key, c.node, clusterOwnerTTL)
+	ctx, cancel := stdContext.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(clusterOwnerTTL / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_ = c.backplane.SetOwner(ctx, key, c.node, clusterOwnerTTL)
+			}
+		}
+	}()
+	return func() {
+		cancel()
+		_ = c.backplane.DelOwner(stdContext.Background(), key, c.node)
+	}
+}
+
+// memoryBackplane 进程内的消息通道，多个Server共用同一个实例时可以模拟集群，用于测试
+type memoryBackplane struct {
+	mu     sync.RWMutex
+	subs   map[string][]*memorySubscriber
+	owners map[string]memoryOwner
+}
+
+// memorySubscriber 订阅者的消息队列，由单独的goroutine按发布顺序调用 handler，与redis的订阅一致
+type memorySubscriber struct {
+	ctx     stdContext.Context
+	handler func(msg []byte)
+	mu      sync.Mutex
+	queue   [][]byte
+	ready   chan struct{}
+}
+
+func (s *memorySubscriber) push(msg []byte) {
+	s.mu.Lock()
+	s.queue = append(s.queue, msg)
+	s.mu.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (s *memorySubscriber) run() {
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-s.ready:
+		}
+		for {
+			s.mu.Lock()
+			if len(s.queue) == 0 {
+				s.mu.Unlock()
+				break
+			}
+			msg := s.queue[0]
+			s.queue[0] = nil
+			s.queue = s.queue[1:]
+			s.mu.Unlock()
+			if s.ctx.Err() != nil {
+				return
+			}
+			s.handler(msg)
+		}
+	}
+}
+
+type memoryOwner struct {
+	node     string
+	deadline time.Time
+}
+
+// NewMemoryBackplane 创建进程内的消息通道
+func NewMemoryBackplane() Backplane {
+	return &memoryBackplane{
+		subs:   make(map[string][]*memorySubscriber),
+		owners: make(map[string]memoryOwner),
+	}
+}
+
+func (b *memoryBackplane) Publish(ctx stdContext.Context, channel string, msg []byte) error {
+	b.mu.RLock()
+	subs := append([]*memorySubscriber(nil), b.subs[channel]...)
+	b.mu.RUnlock()
+	for _, sub := range subs {
+		if sub.ctx.Err() != nil {
+			continue
+		}
+		sub.push(append([]byte(nil), msg...))
+	}
+	return nil
+}
+
+func (b *memoryBackplane) Subscribe(ctx stdContext.Context, channel string, handler func(msg []byte)) error {
+	sub := &memorySubscriber{ctx: ctx, handler: handler, ready: make(chan struct{}, 1)}
+	b.mu.Lock()
+	b.subs[channel] = append(b.subs[channel], sub)
+	b.mu.Unlock()
+	go sub.run()
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		list := b.subs[channel]
+		for i, s := range list {
+			if s == sub {
+				b.subs[channel] = append(list[:i:i], list[i+1:]...)
+				break
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *memoryBackplane) SetOwner(ctx stdContext.Context, key, node string, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.owners[key] = memoryOwner{node: node, deadline: time.Now().Add(ttl)}
+	return nil
+}
+
+func (b *memoryBackplane) Owner(ctx stdContext.Context, key string) (string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	owner, ok := b.owners[key]
+	if !ok || owner.deadline.Before(time.Now()) {
+		return "", nil
+	}
+	return owner.node, nil
+}
+
+func (b *memoryBackplane) DelOwner(ctx stdContext.Context, key, node string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.owners[key].node == node {
+		delete(b.owners, key)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package server
+
+import (
+	stdContext "context"
+	"time"
+
+	"github.com/lazygo/lazygo/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// delOwnerScript 仅当key仍属于当前节点时删除
+const delOwnerScript = `
+	if redis.call("GET", KEYS[1]) == ARGV[1] then
+		return redis.call("DEL", KEYS[1])
+	else
+		return 0
+	end
+`
+
+// redisBackplane 基于 redis pub/sub 的集群消息通道
+type redisBackplane struct {
+	prefix string
+	conn   *goredis.Client
+}
+
+// NewRedisBackplane 创建基于 redis pub/sub 的集群消息通道
+// name 为 redis.Init 中配置的redis名称，prefix 为channel和key的前缀
+func NewRedisBackplane(name, prefix string) (Backplane, error) {
+	conn, err := redis.Client(name)
+	if err != nil {
+		return nil, err
+	}
+	return &redisBackplane{prefix: prefix, conn: conn}, nil
+}
+
+func (b *redisBackplane) Publish(ctx stdContext.Context, channel string, msg []byte) error {
+	return b.conn.Publish(ctx, b.prefix+channel, msg).Err()
+}
+
+func (b *redisBackplane) Subscribe(ctx stdContext.Context, channel string, handler func(msg []byte)) error {
+	pubsub := b.conn.Subscribe(ctx, b.prefix+channel)
+	// 等待订阅确认
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return err
+	}
+	go func() {
+		defer pubsub.Close()
+		ch := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-ch:
+				if !ok {
+					return
+				}
+				handler([]byte(msg.Payload))
+			}
+		}
+	}()
+	return nil
+}
+
+func (b *redisBackplane) SetOwner(ctx stdContext.Context, key, node string, ttl time.Duration) error {
+	return b.conn.Set(ctx, b.prefix+key, node, ttl).Err()
+}
+
+func (b *redisBackplane) Owner(ctx stdContext.Context, key string) (string, error) {
+	node, err := b.conn.Get(ctx, b.prefix+key).Result()
+	if err == goredis.Nil {
+		return "", nil
+	}
+	return node, err
+}
+
+func (b *redisBackplane) DelOwner(ctx stdContext.Context, key, node string) error {
+	return goredis.NewScript(delOwnerScript).Run(ctx, b.conn, []string{b.prefix + key}, node).Err()
+}
@@ -0,0 +1,141 @@
+package server
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type testBridge struct {
+	in  chan *EventData
+	out chan *EventData
+}
+
+func newTestBridge() *testBridge {
+	return &testBridge{in: make(chan *EventData, 8), out: make(chan *EventData, 8)}
+}
+
+func (b *testBridge) Send(data *EventData) error {
+	b.out <- data
+	return nil
+}
+
+func (b *testBridge) Receive(ctx stdContext.Context) (*EventData, error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case data := <-b.in:
+		return data, nil
+	}
+}
+
+func (b *testBridge) Close() error {
+	return nil
+}
+
+func (b *testBridge) next(t *testing.T) *EventData {
+	t.Helper()
+	select {
+	case data := <-b.out:
+		return data
+	case <-time.After(time.Second):
+		t.Fatal("wait event data timeout")
+		return nil
+	}
+}
+
+func TestClusterEvent(t *testing.T) {
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+
+	backplane := NewMemoryBackplane()
+	s1, s2 := New(), New()
+	if err := s1.EnableCluster(ctx, "node1", backplane); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.EnableCluster(ctx, "node2", backplane); err != nil {
+		t.Fatal(err)
+	}
+
+	s2.WebSocket("/hello", func(c Context) error {
+		return c.Blob(http.StatusOK, MIMEApplicationJSON, []byte(`"hello"`))
+	})
+	client, other := newTestBridge(), newTestBridge()
+	go s2.Event(MethodWebSocket, "/ws").Serve(ctx, 1, client)
+	go s2.Event(MethodWebSocket, "/ws").Serve(ctx, 2, other)
+	for i := 0; ; i++ {
+		node, _ := backplane.Owner(ctx, ownerKey(MethodWebSocket, "/ws", 1))
+		if node == "node2" {
+			break
+		}
+		if i > 100 {
+			t.Fatal("cid owner not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 广播到其他节点的连接
+	err := s1.Event(MethodWebSocket, "/ws").Broadcast(ctx, &EventData{URI: "/notice", Body: json.RawMessage(`"hi"`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := client.next(t); data.URI != "/notice" {
+		t.Errorf("broadcast uri = %s, want /notice", data.URI)
+	}
+	other.next(t)
+
+	// 请求转发到cid所在节点，响应返回给调用方
+	wait, err := s1.Event(MethodWebSocket, "/ws").Request(ctx, 1, &EventData{RID: 7, URI: "/ask"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data := client.next(t); data.RID != 7 || data.URI != "/ask" {
+		t.Errorf("forwarded request = %+v", data)
+	}
+	// 其他连接使用相同rid的请求不是转发请求的响应
+	other.in <- &EventData{RID: 7, URI: "/hello", Header: http.Header{}}
+	if data := other.next(t); data.RID != 7 || string(data.Body) != `"hello"` {
+		t.Errorf("local request with forwarded rid = %+v", data)
+	}
+	client.in <- &EventData{RID: 7, URI: "/ask", Body: json.RawMessage(`{"ok":true}`)}
+	resp, err := wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Body) != `{"ok":true}` {
+		t.Errorf("response body = %s", resp.Body)
+	}
+
+	if _, err := s1.Event(MethodWebSocket, "/ws").Request(ctx, 3, &EventData{RID: 8, URI: "/ask"}); err != ErrEventCIDNotExists {
+		t.Errorf("request unknown cid err = %v, want %v", err, ErrEventCIDNotExists)
+	}
+}
+
+func TestMemoryBackplaneOrder(t *testing.T) {
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+	b := NewMemoryBackplane()
+	got := make(chan string, 100)
+	if err := b.Subscribe(ctx, "ch", func(msg []byte) { got <- string(msg) }); err != nil {
+		t.Fatal(err)
+	}
+	for i := range 100 {
+		if err := b.Publish(ctx, "ch", []byte(strconv.Itoa(i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 与redis订阅一致，按发布顺序送达
+	for i := range 100 {
+		select {
+		case msg := <-got:
+			if msg != strconv.Itoa(i) {
+				t.Fatalf("message %d = %s", i, msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("message %d not delivered", i)
+		}
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"mime/multipart"
 	"net/http"
 	"net/url"
@@ -80,6 +81,11 @@ type (
 		// RequestHeader 获取请求头
 		RequestHeader(name string) string
 
+		// RealIP 获取客户端ip，仅信任来自可信代理的转发头
+		RealIP() string
+		// Scheme 获取客户端请求使用的协议，http 或 https
+		Scheme() string
+
 		// JSON sends a JSON response with status code.
 		JSON(code int, i any) error
 		// Blob sends a blob response with status code and content type.
@@ -89,6 +95,13 @@ type (
 		HTML(code int, html string) error
 		// HTMLBlob sends an HTTP blob response with status code.
 		HTMLBlob(code int, b []byte) error
+		// Render 使用 Server.Renderer 渲染模板并输出html
+		Render(code int, name string, data any) error
+
+		// CSV 逐行读取 rows 并以CSV格式流式输出，见 Rows 和 ExportConfig
+		CSV(code int, rows iter.Seq2[any, error], conf ...ExportConfig) error
+		// NDJSON 逐行读取 rows 并以每行一个json对象的格式流式输出
+		NDJSON(code int, rows iter.Seq2[any, error], conf ...ExportConfig) error
 
 		// Stream sends a streaming response with status code and content type.
 		Stream(code int, contentType string, r io.Reader) error
@@ -114,11 +127,24 @@ type (
 		// Event 获取event对象
 		Event(method, subject string) *Event
 
+		// WaitFor 长轮询，等待 Server.Notify 发布key的通知，超时返回 ErrWaitTimeout
+		// timeout 为0时默认30秒，客户端断开时返回请求context的错误
+		WaitFor(key string, timeout time.Duration) (json.RawMessage, error)
+
 		// Handler returns the matched handler by router.
 		Handler() HandlerFunc
 
 		// IsDebug return the Server is debug.
 		IsDebug() bool
+
+		// APIVersion 版本路由解析出的API版本，不是版本路由时为空，见 Versions
+		APIVersion() string
+
+		// RouteMeta 当前路由的元数据，没有匹配到路由时为nil，见 Meta
+		RouteMeta() Meta
+
+		// OriginalPath Mount 去掉前缀之前的请求路径，不是挂载的请求时为当前路径
+		OriginalPath() string
 	}
 
 	context struct {
@@ -131,6 +157,8 @@ type (
 		handler        HandlerFunc
 		store          Map
 		server         *Server
+		apiVersion     string
+		route          *RouteInfo
 		lock           sync.RWMutex
 	}
 )
@@ -175,6 +203,11 @@ func (c *context) GetRoutePath() string {
 	return c.path
 }
 
+// SetRoutePath 设置路由路径，用于不经过路由直接调用处理函数，如 servertest，不属于 Context 接口
+func (c *context) SetRoutePath(path string) {
+	c.path = path
+}
+
 func (c *context) Bind(v any) error {
 	// result pointer value
 	rpv := reflect.ValueOf(v)
@@ -301,6 +334,14 @@ func (c *context) ParamValues() []string {
 	return c.pvalues[:len(c.pnames)]
 }
 
+// SetParams 设置路由参数，用于不经过路由直接调用处理函数，如 servertest，不属于 Context 接口
+func (c *context) SetParams(names, values []string) {
+	c.pnames = names
+	// pvalues 的长度需保持不小于 maxParam
+	c.pvalues = make([]string, max(*c.s().maxParam, len(names), len(values)))
+	copy(c.pvalues, values)
+}
+
 // GetString 获取Get字符串变量
 func (c *context) GetString(name string, defVal ...string) string {
 	return utils.ToString(c.QueryParam(name), defVal...)
@@ -418,6 +459,18 @@ func (c *context) RequestHeader(name string) string {
 	return c.request.Header.Get(name)
 }
 
+// RealIP 获取客户端ip
+func (c *context) RealIP() string {
+	ip, _ := c.s().realIP(c)
+	return ip
+}
+
+// Scheme 获取客户端请求协议
+func (c *context) Scheme() string {
+	_, scheme := c.s().realIP(c)
+	return scheme
+}
+
 // Cookie cookie
 func (c *context) Cookie(name string) (string, bool) {
 	val, err := c.request.Cookie(name)
@@ -479,6 +532,19 @@ func (c *context) HTMLBlob(code int, b []byte) error {
 	return c.Blob(code, MIMETextHTMLCharsetUTF8, b)
 }
 
+// Render 渲染模板，先渲染到缓冲区，渲染失败时不会输出不完整的响应
+func (c *context) Render(code int, name string, data any) error {
+	r := c.s().Renderer
+	if r == nil {
+		return ErrRendererNotRegistered
+	}
+	buf := new(bytes.Buffer)
+	if err := r.Render(buf, name, data, c); err != nil {
+		return err
+	}
+	return c.HTMLBlob(code, buf.Bytes())
+}
+
 func (c *context) Attachment(file, name string) error {
 	return c.contentDisposition(file, name, "attachment")
 }
@@ -523,6 +589,10 @@ func (c *context) IsDebug() bool {
 	return c.s().Debug
 }
 
+func (c *context) APIVersion() string {
+	return c.apiVersion
+}
+
 // Deadline returns that there is no deadline (ok==false) when c.Request has no Context.
 func (c *context) Deadline() (deadline time.Time, ok bool) {
 	return c.request.Context().Deadline()
@@ -564,6 +634,8 @@ func (c *context) reset(r *http.Request, w http.ResponseWriter) {
 	c.path = ""
 	c.pnames = nil
 	c.query = nil
+	c.apiVersion = ""
+	c.route = nil
 	// NOTE: Don't reset because it has to have length c.engine.maxParam at all times
 	for i := 0; i < *c.s().maxParam; i++ {
 		c.pvalues[i] = ""
@@ -1,6 +1,7 @@
 package server
 
 import (
+	"errors"
 	"fmt"
 	"reflect"
 	"strings"
@@ -30,41 +31,15 @@ func Controller(h any, methodName ...string) HandlerFunc {
 		if !ok {
 			return ErrNotFound.SetInternal(fmt.Errorf("method name %s not found", name))
 		}
-		rCtx := reflect.ValueOf(ctx)
-
-		pServ := reflect.New(rtServ)
-		args := []reflect.Value{pServ}
-		var req any
-
-		if method.Request != nil {
-			pReq := reflect.New(method.Request)
-			req = pReq.Interface()
-
-			if req, ok := req.(interface{ Clear() }); ok {
-				defer req.Clear()
-			}
-
-			if err = ctx.Bind(req); err != nil {
-				return ErrBadRequest.SetInternal(fmt.Errorf("bind params error, req: %v, err: %v", req, err))
-			}
-			verify := pReq.MethodByName("Verify")
-			var params []reflect.Value
-			if verify.Type().NumIn() > 0 {
-				params = append(params, rCtx)
-			}
-			err := verify.Call(params)[0].Interface()
-			if err != nil {
-				if he, ok := err.(*HTTPError); ok {
-					return he.SetInternal(fmt.Errorf("verify params fail, req: %v", req))
-				}
-				return ErrBadRequest.SetInternal(fmt.Errorf("params error, req: %v, err: %v", req, err))
-			}
-			args = append(args, pReq)
+		pReq, req := newRequest(method)
+		if req, ok := req.(interface{ Clear() }); ok {
+			defer req.Clear()
+		}
+		if err := bindRequest(ctx, pReq, req); err != nil {
+			return err
 		}
 
-		pServ.Elem().FieldByName("Ctx").Set(rCtx)
-
-		out := method.Method.Func.Call(args)
+		out := callMethod(ctx, rtServ, method, pReq)
 		numOut := len(out)
 		if numOut == 1 {
 			if ierr := out[0].Interface(); ierr != nil {
@@ -94,3 +69,51 @@ func Controller(h any, methodName ...string) HandlerFunc {
 		return ErrInternalServerError
 	}
 }
+
+// newRequest 创建方法的请求参数，方法没有请求参数时返回零值
+func newRequest(method Route) (reflect.Value, any) {
+	if method.Request == nil {
+		return reflect.Value{}, nil
+	}
+	pReq := reflect.New(method.Request)
+	return pReq, pReq.Interface()
+}
+
+// bindRequest 绑定并校验请求参数
+func bindRequest(ctx Context, pReq reflect.Value, req any) error {
+	if !pReq.IsValid() {
+		return nil
+	}
+	if err := ctx.Bind(req); err != nil {
+		return ErrBadRequest.SetInternal(fmt.Errorf("bind params error, req: %v, err: %v", req, err))
+	}
+	verify := pReq.MethodByName("Verify")
+	var params []reflect.Value
+	if verify.Type().NumIn() > 0 {
+		params = append(params, reflect.ValueOf(ctx))
+	}
+	err := verify.Call(params)[0].Interface()
+	if err != nil {
+		if he, ok := err.(*HTTPError); ok {
+			return he.SetInternal(fmt.Errorf("verify params fail, req: %v", req))
+		}
+		// Verify 返回 ValidationError 时输出为422，ProblemHTTPErrorHandler 输出字段错误
+		var ve *ValidationError
+		if errors.As(err.(error), &ve) {
+			return ErrUnprocessableEntity.SetInternal(fmt.Errorf("verify params fail, req: %v, err: %w", req, ve))
+		}
+		return ErrBadRequest.SetInternal(fmt.Errorf("params error, req: %v, err: %v", req, err))
+	}
+	return nil
+}
+
+// callMethod 创建控制器实例并调用方法
+func callMethod(ctx Context, rtServ reflect.Type, method Route, pReq reflect.Value) []reflect.Value {
+	pServ := reflect.New(rtServ)
+	args := []reflect.Value{pServ}
+	if pReq.IsValid() {
+		args = append(args, pReq)
+	}
+	pServ.Elem().FieldByName("Ctx").Set(reflect.ValueOf(ctx))
+	return method.Method.Func.Call(args)
+}
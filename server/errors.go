@@ -18,11 +18,14 @@ var (
 	ErrStatusRequestEntityTooLarge = NewHTTPError(http.StatusRequestEntityTooLarge)
 	ErrTooManyRequests             = NewHTTPError(http.StatusTooManyRequests)
 	ErrBadRequest                  = NewHTTPError(http.StatusBadRequest)
+	ErrConflict                    = NewHTTPError(http.StatusConflict)
+	ErrUnprocessableEntity         = NewHTTPError(http.StatusUnprocessableEntity)
 	ErrBadGateway                  = NewHTTPError(http.StatusBadGateway)
 	ErrInternalServerError         = NewHTTPError(http.StatusInternalServerError)
 	ErrRequestTimeout              = NewHTTPError(http.StatusRequestTimeout)
 	ErrServiceUnavailable          = NewHTTPError(http.StatusServiceUnavailable)
 	ErrValidatorNotRegistered      = errors.New("validator not registered")
+	ErrRendererNotRegistered       = errors.New("renderer not registered")
 	ErrActionNotExists             = errors.New("action not exists")
 	ErrInvalidRedirectCode         = errors.New("invalid redirect status code")
 	ErrCookieNotFound              = errors.New("cookie not found")
@@ -46,6 +49,10 @@ type HTTPError struct {
 	Errno    int   `json:"errno"`
 	Message  any   `json:"message"`
 	Internal error `json:"-"` // Stores the error returned by an external dependency
+	// Key 消息key，设置了 Server.Translator 时按请求语言翻译后替换 Message
+	Key string `json:"-"`
+	// Params 翻译消息的占位符参数
+	Params map[string]any `json:"-"`
 }
 
 // NewHTTPError creates a new HTTPError instance.
@@ -78,6 +85,16 @@ func (he HTTPError) SetInternal(err error) *HTTPError {
 	return &he
 }
 
+// WithKey copy and set message key, Message is used when the key can't be translated
+func (he HTTPError) WithKey(key string, params ...map[string]any) *HTTPError {
+	he.Key = key
+	he.Params = nil
+	if len(params) > 0 {
+		he.Params = params[0]
+	}
+	return &he
+}
+
 // Unwrap satisfies the Go 1.13 error wrapper interface.
 func (he *HTTPError) Unwrap() error {
 	return he.Internal
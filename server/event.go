@@ -11,6 +11,7 @@ import (
 	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -24,8 +25,9 @@ var (
 )
 
 type EventManager struct {
-	event  sync.Map
-	server *Server
+	event   sync.Map
+	server  *Server
+	cluster *cluster
 }
 
 func (em *EventManager) Get(method, subject string) *Event {
@@ -35,6 +37,15 @@ func (em *EventManager) Get(method, subject string) *Event {
 		subject: subject,
 		src:     make(map[uint64]SendReceiveCloser),
 		waiter:  waiter.NewWaiter[*EventData](),
+		conf: EventConfig{
+			Workers:       defaultEventWorkers,
+			SendQueueSize: defaultEventSendQueueSize,
+		},
+		room: eventRoomState{
+			rooms:  make(map[string]map[uint64]struct{}),
+			joined: make(map[uint64]map[string]struct{}),
+			meta:   make(map[uint64]map[string]string),
+		},
 	})
 	return e.(*Event)
 }
@@ -52,24 +63,67 @@ type Event struct {
 	subject string
 	src     map[uint64]SendReceiveCloser
 	waiter  *waiter.Waiter[*EventData]
+	// forwarded 其他节点转发来的请求（cid:rid）及来源节点，客户端响应时转发回来源节点
+	forwarded sync.Map
+	room      eventRoomState
+	conf      EventConfig
+	counters  eventCounters
+	log       EventLog
+	auth      EventAuth
 }
 
 func (e *Event) Serve(ctx stdContext.Context, cid uint64, src SendReceiveCloser) error {
+	return e.ServeFrom(ctx, cid, src, 0)
+}
+
+// ServeFrom 与 Serve 相同，设置了 EventLog 且 lastID 大于0时，
+// 先向连接补发 lastID 之后的广播消息，再发送实时消息
+func (e *Event) ServeFrom(ctx stdContext.Context, cid uint64, src SendReceiveCloser, lastID uint64) error {
+	e.mu.RLock()
+	auth := e.auth
+	e.mu.RUnlock()
+	ctx, err := e.authenticate(ctx, cid, auth)
+	if err != nil {
+		_ = src.Close()
+		return err
+	}
+
 	e.mu.Lock()
 	if _, ok := e.src[cid]; ok {
 		e.mu.Unlock()
 		return errors.New("cid already exists")
 	}
-	e.src[cid] = src
+	ctx, cancel := stdContext.WithCancel(ctx)
+	defer cancel()
+	conf := e.conf
+	conn := newEventConn(src, &e.counters, conf, cancel)
+	e.src[cid] = conn
+	log := e.log
 	e.mu.Unlock()
 
-	e.serve(ctx, src)
+	// 连接注册后再读取历史消息，期间的实时消息在发送队列中，按id去重
+	var replay func() ([]*EventData, error)
+	if log != nil && lastID > 0 {
+		replay = func() ([]*EventData, error) {
+			return log.Since(ctx, e.logKey(), lastID)
+		}
+	}
+	go conn.writeLoop(replay)
+
+	if c := e.server.eventManager.cluster; c != nil {
+		unregister := c.register(ctx, ownerKey(e.method, e.subject, cid))
+		defer unregister()
+	}
 
+	e.serve(ctx, cid, conn, conf.Workers, auth)
+
+	e.leaveAll(cid)
 	e.mu.Lock()
 	delete(e.src, cid)
 	e.mu.Unlock()
+	conn.shutdown()
 
-	err := src.Close()
+	err = src.Close()
 	if err != nil {
 		return err
 	}
@@ -77,7 +131,38 @@ func (e *Event) Serve(ctx stdContext.Context, cid uint64, src SendReceiveCloser)
 	return nil
 }
 
+// Broadcast 向所有连接发送数据，启用集群时同时转发到其他节点
+// 设置了 EventLog 时，消息先写入日志并分配递增的id
 func (e *Event) Broadcast(ctx stdContext.Context, data *EventData) error {
+	e.mu.RLock()
+	log := e.log
+	e.mu.RUnlock()
+	if log != nil {
+		stamped := *data
+		id, err := log.Append(ctx, e.logKey(), &stamped)
+		if err != nil {
+			return err
+		}
+		stamped.ID = id
+		data = &stamped
+	}
+	err := e.broadcastLocal(data)
+	if c := e.server.eventManager.cluster; c != nil {
+		perr := c.publish(ctx, clusterBroadcastChannel, &clusterMessage{
+			Type:    clusterMsgBroadcast,
+			Method:  e.method,
+			Subject: e.subject,
+			Data:    data,
+		})
+		if perr != nil {
+			err = errors.Join(err, perr)
+		}
+	}
+	return err
+}
+
+// broadcastLocal 向当前节点的所有连接发送数据
+func (e *Event) broadcastLocal(data *EventData) error {
 	var list []SendReceiveCloser
 	e.mu.RLock()
 	for _, src := range e.src {
@@ -95,16 +180,18 @@ func (e *Event) Broadcast(ctx stdContext.Context, data *EventData) error {
 	return errs
 }
 
+// Request 向指定连接发送请求，返回等待响应的函数
+// 启用集群时，cid不在当前节点会转发到其所在的节点
 func (e *Event) Request(ctx stdContext.Context, cid uint64, data *EventData) (func() (*EventData, error), error) {
 	e.mu.RLock()
 	src, ok := e.src[cid]
 	e.mu.RUnlock()
-	if !ok {
-		return nil, ErrEventCIDNotExists
-	}
 	if data.RID == 0 {
 		return nil, ErrEventRIDRequired
 	}
+	if !ok {
+		return e.forward(ctx, cid, data)
+	}
 	wait, cancel := e.waiter.Get(ctx, strconv.FormatUint(data.RID, 10))
 	err := src.Send(data)
 	if err != nil {
@@ -114,7 +201,40 @@ func (e *Event) Request(ctx stdContext.Context, cid uint64, data *EventData) (fu
 	return wait, nil
 }
 
-func (e *Event) serve(ctx stdContext.Context, src SendReceiveCloser) error {
+// forward 将请求转发到cid所在的节点
+func (e *Event) forward(ctx stdContext.Context, cid uint64, data *EventData) (func() (*EventData, error), error) {
+	c := e.server.eventManager.cluster
+	if c == nil {
+		return nil, ErrEventCIDNotExists
+	}
+	node, err := c.backplane.Owner(ctx, ownerKey(e.method, e.subject, cid))
+	if err != nil {
+		return nil, err
+	}
+	if node == "" || node == c.node {
+		return nil, ErrEventCIDNotExists
+	}
+	wait, cancel := e.waiter.Get(ctx, strconv.FormatUint(data.RID, 10))
+	err = c.publish(ctx, clusterNodeChannel+node, &clusterMessage{
+		Type:    clusterMsgRequest,
+		Method:  e.method,
+		Subject: e.subject,
+		CID:     cid,
+		Data:    data,
+	})
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return wait, nil
+}
+
+// serve 读取客户端消息并交给处理函数，同时处理的消息数达到 workers 时暂停读取
+// 返回前等待处理中的消息完成，避免连接关闭后再发送响应
+func (e *Event) serve(ctx stdContext.Context, cid uint64, src SendReceiveCloser, workers int, auth EventAuth) error {
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	defer wg.Wait()
 	for {
 		select {
 		case <-ctx.Done():
@@ -127,13 +247,36 @@ func (e *Event) serve(ctx stdContext.Context, src SendReceiveCloser) error {
 			if req == nil {
 				continue
 			}
-			go e.handle(ctx, src, req)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case sem <- struct{}{}:
+			}
+			e.counters.inflight.Add(1)
+			wg.Add(1)
+			go func() {
+				defer func() {
+					e.counters.inflight.Add(-1)
+					<-sem
+					wg.Done()
+				}()
+				e.handle(ctx, cid, src, req, auth)
+			}()
 		}
 	}
 }
 
-func (e *Event) handle(ctx stdContext.Context, src SendReceiveCloser, req *EventData) error {
+func (e *Event) handle(ctx stdContext.Context, cid uint64, src SendReceiveCloser, req *EventData, auth EventAuth) error {
 	if req.RID > 0 {
+		if node, ok := e.forwarded.LoadAndDelete(forwardKey(cid, req.RID)); ok {
+			// 其他节点转发来的请求的响应，返回给来源节点
+			return e.server.eventManager.cluster.publish(ctx, clusterNodeChannel+node.(string), &clusterMessage{
+				Type:    clusterMsgResponse,
+				Method:  e.method,
+				Subject: e.subject,
+				Data:    req,
+			})
+		}
 		ctxT, cancel := stdContext.WithTimeout(ctx, time.Second)
 		defer cancel()
 		ok, err := e.waiter.Put(ctxT, strconv.FormatUint(req.RID, 10), req)
@@ -151,29 +294,24 @@ func (e *Event) handle(ctx stdContext.Context, src SendReceiveCloser, req *Event
 			return nil
 		}
 	}
-	// 使用 buffer 收集完整响应，避免 ServeHTTP 多次 Write 被拆成多条 WebSocket 消息
-	buf := &bytes.Buffer{}
-	w := &eventResponseWriter{ctx: ctx, Writer: buf, header: http.Header{}}
+	if err := e.authorize(ctx, auth, req); err != nil {
+		e.replyError(ctx, src, req, err)
+		return err
+	}
+	if req.URI == EventURIJoin || req.URI == EventURILeave {
+		e.handleRoom(cid, src, req)
+		return nil
+	}
 	r, err := newEventRequest(ctx, e.method, req)
 	if err != nil {
-		c := e.server.AcquireContext()
-		c.SetRequest(&http.Request{})
-		c.SetResponseWriter(NewResponseWriter(w))
-		c.Error(err)
-		e.server.ReleaseContext(c)
-
 		e.server.Logger.Printf("[msg: new websocket request error] [err: %v]", err)
-		if buf.Len() > 0 {
-			_ = src.Send(&EventData{
-				RID:    req.RID,
-				URI:    req.URI,
-				Header: w.Header(),
-				Body:   buf.Bytes(),
-			})
-		}
+		e.replyError(ctx, src, req, err)
 		return err
 	}
 
+	// 使用 buffer 收集完整响应，避免 ServeHTTP 多次 Write 被拆成多条 WebSocket 消息
+	buf := &bytes.Buffer{}
+	w := &eventResponseWriter{ctx: ctx, Writer: buf, header: http.Header{}}
 	e.server.ServeHTTP(w, r)
 	// 整份响应作为一条 WebSocket 消息发送，保证不被截断
 	if buf.Len() > 0 {
@@ -187,6 +325,26 @@ func (e *Event) handle(ctx stdContext.Context, src SendReceiveCloser, req *Event
 	return nil
 }
 
+// replyError 使用 HTTPErrorHandler 生成错误响应并发送给客户端
+func (e *Event) replyError(ctx stdContext.Context, src SendReceiveCloser, req *EventData, err error) {
+	buf := &bytes.Buffer{}
+	w := &eventResponseWriter{ctx: ctx, Writer: buf, header: http.Header{}}
+	c := e.server.AcquireContext()
+	c.SetRequest(&http.Request{})
+	c.SetResponseWriter(NewResponseWriter(w))
+	c.Error(err)
+	e.server.ReleaseContext(c)
+
+	if buf.Len() > 0 {
+		_ = src.Send(&EventData{
+			RID:    req.RID,
+			URI:    req.URI,
+			Header: w.Header(),
+			Body:   buf.Bytes(),
+		})
+	}
+}
+
 type eventResponseWriter struct {
 	ctx stdContext.Context
 	io.Writer
@@ -204,7 +362,9 @@ func (w *eventResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 }
 
 type EventData struct {
-	RID    uint64          `json:"rid"`
+	RID uint64 `json:"rid"`
+	// ID 广播消息在 EventLog 中的id，用于断线重连后补发
+	ID     uint64          `json:"id,omitempty"`
 	URI    string          `json:"uri"`
 	Header http.Header     `json:"header,omitempty"`
 	Body   json.RawMessage `json:"body"`
@@ -216,6 +376,10 @@ func newEventRequest(ctx stdContext.Context, method string, e *EventData) (*http
 		return nil, ErrEventURIRequired
 	}
 
+	// X-HTTP-Method-Override 指定路由的请求方法，如 eventclient 的 Request，未指定时使用Event的方法
+	if m := e.Header.Get(HeaderXHTTPMethodOverride); m != "" {
+		method = strings.ToUpper(m)
+	}
 	ctx = stdContext.WithValue(ctx, HeaderXRequestID, e.RID)
 	req, err := http.NewRequestWithContext(ctx, method, e.URI, bytes.NewReader(e.Body))
 	if err != nil {
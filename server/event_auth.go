@@ -0,0 +1,70 @@
+package server
+
+import (
+	stdContext "context"
+	"errors"
+)
+
+type eventPrincipalKey struct{}
+
+// EventAuth 连接的认证和授权
+type EventAuth struct {
+	// Authenticate 建立连接时调用，返回连接的身份，返回错误时拒绝连接。
+	// ctx 为调用 Serve 时传入的ctx，可从中读取握手请求的请求头或cookie
+	Authenticate func(ctx stdContext.Context, cid uint64) (principal any, err error)
+	// Authorize 处理客户端的每个请求前调用，返回错误时拒绝该请求，
+	// 返回 *HTTPError 时按其状态码响应，其他错误响应403
+	Authorize func(ctx stdContext.Context, principal any, req *EventData) error
+	// StripHeaders 从客户端请求中删除的请求头，避免客户端在单个请求中伪造身份，
+	// 设置了 Authenticate 时默认为 Authorization 和 Cookie
+	StripHeaders []string
+}
+
+// SetAuth 设置连接的认证和授权，只对之后建立的连接生效
+func (e *Event) SetAuth(auth EventAuth) {
+	if auth.Authenticate != nil && auth.StripHeaders == nil {
+		auth.StripHeaders = []string{HeaderAuthorization, HeaderCookie}
+	}
+	e.mu.Lock()
+	e.auth = auth
+	e.mu.Unlock()
+}
+
+// EventPrincipal 获取请求所属连接的身份，由 EventAuth.Authenticate 返回，
+// 客户端无法通过请求头覆盖。非Event请求或未设置认证时返回nil
+func EventPrincipal(ctx stdContext.Context) any {
+	return ctx.Value(eventPrincipalKey{})
+}
+
+// authenticate 建立连接时认证，返回携带身份的ctx
+func (e *Event) authenticate(ctx stdContext.Context, cid uint64, auth EventAuth) (stdContext.Context, error) {
+	if auth.Authenticate == nil {
+		return ctx, nil
+	}
+	principal, err := auth.Authenticate(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+	return stdContext.WithValue(ctx, eventPrincipalKey{}, principal), nil
+}
+
+// authorize 校验请求，并删除客户端不允许设置的请求头
+func (e *Event) authorize(ctx stdContext.Context, auth EventAuth, req *EventData) error {
+	if auth.Authorize != nil {
+		if err := auth.Authorize(ctx, EventPrincipal(ctx), req); err != nil {
+			var he *HTTPError
+			if errors.As(err, &he) {
+				return err
+			}
+			return ErrForbidden.SetInternal(err)
+		}
+	}
+	if len(auth.StripHeaders) > 0 && req.Header != nil {
+		header := req.Header.Clone()
+		for _, h := range auth.StripHeaders {
+			header.Del(h)
+		}
+		req.Header = header
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestEventAuth(t *testing.T) {
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+
+	s := New()
+	s.WebSocket("/me", func(c Context) error {
+		return c.JSON(http.StatusOK, Map{
+			"principal":     EventPrincipal(c.Request().Context()),
+			"authorization": c.RequestHeader(HeaderAuthorization),
+		})
+	})
+	s.WebSocket("/admin", func(c Context) error {
+		return c.Blob(http.StatusOK, MIMETextPlain, []byte("admin"))
+	})
+
+	e := s.Event(MethodWebSocket, "/ws")
+	e.SetAuth(EventAuth{
+		Authenticate: func(ctx stdContext.Context, cid uint64) (any, error) {
+			if cid == 2 {
+				return nil, errors.New("invalid token")
+			}
+			return "user1", nil
+		},
+		Authorize: func(ctx stdContext.Context, principal any, req *EventData) error {
+			if strings.HasPrefix(req.URI, "/admin") {
+				return errors.New("admin only")
+			}
+			return nil
+		},
+	})
+
+	if err := e.Serve(ctx, 2, newTestBridge()); err == nil {
+		t.Error("expect authenticate error")
+	}
+
+	client := newTestBridge()
+	go e.Serve(ctx, 1, client)
+
+	// 客户端伪造的 Authorization 被删除
+	client.in <- &EventData{RID: 1, URI: "/me", Header: http.Header{HeaderAuthorization: {"Bearer forged"}}}
+	var me map[string]string
+	if err := json.Unmarshal(client.next(t).Body, &me); err != nil {
+		t.Fatal(err)
+	}
+	if me["principal"] != "user1" || me["authorization"] != "" {
+		t.Errorf("me = %v", me)
+	}
+
+	client.in <- &EventData{RID: 2, URI: "/admin", Header: http.Header{}}
+	var resp map[string]any
+	data := client.next(t)
+	if err := json.Unmarshal(data.Body, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if data.RID != 2 || resp["code"] != float64(http.StatusForbidden) {
+		t.Errorf("admin = %d %s", data.RID, data.Body)
+	}
+}
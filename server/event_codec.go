@@ -0,0 +1,172 @@
+package server
+
+import (
+	"encoding/json"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/coder/websocket"
+	"github.com/fxamacker/cbor/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// WebSocket子协议，客户端在握手时通过 Sec-WebSocket-Protocol 选择编码，未指定时使用json
+const (
+	SubprotocolJSON    = "lazygo.json"
+	SubprotocolMsgpack = "lazygo.msgpack"
+	SubprotocolCBOR    = "lazygo.cbor"
+)
+
+// HeaderEventBodyEncoding json编码时标记body的编码方式，值为base64时body为base64编码的二进制数据
+const HeaderEventBodyEncoding = "X-Event-Body-Encoding"
+
+// EventCodec EventData 的编解码器
+type EventCodec interface {
+	// Subprotocol 协商使用的WebSocket子协议
+	Subprotocol() string
+	// MessageType 消息帧类型
+	MessageType() websocket.MessageType
+	Marshal(data *EventData) ([]byte, error)
+	Unmarshal(msg []byte, data *EventData) error
+}
+
+var (
+	codecMu sync.RWMutex
+	codecs  = map[string]EventCodec{}
+	// codecOrder 按注册顺序返回子协议，json优先
+	codecOrder []string
+)
+
+func init() {
+	RegisterEventCodec(jsonCodec{})
+	RegisterEventCodec(msgpackCodec{})
+	RegisterEventCodec(cborCodec{})
+}
+
+// RegisterEventCodec 注册编解码器，子协议相同时覆盖
+func RegisterEventCodec(codec EventCodec) {
+	codecMu.Lock()
+	defer codecMu.Unlock()
+	if _, ok := codecs[codec.Subprotocol()]; !ok {
+		codecOrder = append(codecOrder, codec.Subprotocol())
+	}
+	codecs[codec.Subprotocol()] = codec
+}
+
+// EventCodecFor 获取子协议对应的编解码器，未协商或不支持时返回json编解码器
+func EventCodecFor(subprotocol string) EventCodec {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	if codec, ok := codecs[subprotocol]; ok {
+		return codec
+	}
+	return jsonCodec{}
+}
+
+// WebSocketSubprotocols 已注册的子协议，用于 websocket.AcceptOptions.Subprotocols
+func WebSocketSubprotocols() []string {
+	codecMu.RLock()
+	defer codecMu.RUnlock()
+	return append([]string(nil), codecOrder...)
+}
+
+// eventFrame 二进制编码的消息结构，Body 为原始字节，不需要是json
+type eventFrame struct {
+	RID    uint64              `msgpack:"rid" cbor:"rid"`
+	ID     uint64              `msgpack:"id,omitempty" cbor:"id,omitempty"`
+	URI    string              `msgpack:"uri" cbor:"uri"`
+	Header map[string][]string `msgpack:"header,omitempty" cbor:"header,omitempty"`
+	Body   []byte              `msgpack:"body,omitempty" cbor:"body,omitempty"`
+}
+
+func newEventFrame(data *EventData) *eventFrame {
+	return &eventFrame{RID: data.RID, ID: data.ID, URI: data.URI, Header: data.Header, Body: data.Body}
+}
+
+func (f *eventFrame) decode(data *EventData) {
+	data.RID, data.ID, data.URI, data.Header, data.Body = f.RID, f.ID, f.URI, f.Header, f.Body
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Subprotocol() string                { return SubprotocolJSON }
+func (jsonCodec) MessageType() websocket.MessageType { return websocket.MessageText }
+
+func (jsonCodec) Marshal(data *EventData) ([]byte, error) {
+	if len(data.Body) > 0 && !json.Valid(data.Body) {
+		clone := *data
+		if utf8.Valid(data.Body) {
+			// 非json的文本body作为字符串发送
+			body, err := json.Marshal(string(data.Body))
+			if err != nil {
+				return nil, err
+			}
+			clone.Body = body
+		} else {
+			// 二进制body（如msgpack、CBOR连接发送的数据）使用base64编码，避免无效的utf-8被替换
+			body, err := json.Marshal([]byte(data.Body))
+			if err != nil {
+				return nil, err
+			}
+			clone.Body = body
+			clone.Header = data.Header.Clone()
+			if clone.Header == nil {
+				clone.Header = make(map[string][]string)
+			}
+			clone.Header.Set(HeaderEventBodyEncoding, "base64")
+		}
+		data = &clone
+	}
+	return json.Marshal(data)
+}
+
+func (jsonCodec) Unmarshal(msg []byte, data *EventData) error {
+	if err := json.Unmarshal(msg, data); err != nil {
+		return err
+	}
+	if data.Header.Get(HeaderEventBodyEncoding) == "base64" {
+		var body []byte
+		if err := json.Unmarshal(data.Body, &body); err != nil {
+			return err
+		}
+		data.Body = body
+		data.Header.Del(HeaderEventBodyEncoding)
+	}
+	return nil
+}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Subprotocol() string                { return SubprotocolMsgpack }
+func (msgpackCodec) MessageType() websocket.MessageType { return websocket.MessageBinary }
+
+func (msgpackCodec) Marshal(data *EventData) ([]byte, error) {
+	return msgpack.Marshal(newEventFrame(data))
+}
+
+func (msgpackCodec) Unmarshal(msg []byte, data *EventData) error {
+	var f eventFrame
+	if err := msgpack.Unmarshal(msg, &f); err != nil {
+		return err
+	}
+	f.decode(data)
+	return nil
+}
+
+type cborCodec struct{}
+
+func (cborCodec) Subprotocol() string                { return SubprotocolCBOR }
+func (cborCodec) MessageType() websocket.MessageType { return websocket.MessageBinary }
+
+func (cborCodec) Marshal(data *EventData) ([]byte, error) {
+	return cbor.Marshal(newEventFrame(data))
+}
+
+func (cborCodec) Unmarshal(msg []byte, data *EventData) error {
+	var f eventFrame
+	if err := cbor.Unmarshal(msg, &f); err != nil {
+		return err
+	}
+	f.decode(data)
+	return nil
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	stdContext "context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func TestEventCodec(t *testing.T) {
+	binary := []byte{0x00, 0xff, 0x10, 0x80}
+	for _, sub := range []string{SubprotocolMsgpack, SubprotocolCBOR} {
+		codec := EventCodecFor(sub)
+		if codec.MessageType() != websocket.MessageBinary {
+			t.Errorf("%s message type = %v", sub, codec.MessageType())
+		}
+		msg, err := codec.Marshal(&EventData{RID: 7, URI: "/file", Header: http.Header{"X-A": {"1"}}, Body: binary})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var data EventData
+		if err := codec.Unmarshal(msg, &data); err != nil {
+			t.Fatal(err)
+		}
+		if data.RID != 7 || data.URI != "/file" || data.Header.Get("X-A") != "1" || !bytes.Equal(data.Body, binary) {
+			t.Errorf("%s decode = %+v", sub, data)
+		}
+	}
+
+	// json编码时非json的body作为字符串发送
+	msg, err := EventCodecFor("").Marshal(&EventData{RID: 1, URI: "/text", Body: []byte("hello")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"rid":1,"uri":"/text","body":"hello"}`; string(msg) != want {
+		t.Errorf("json = %s, want %s", msg, want)
+	}
+
+	// 二进制body使用base64编码，解码后与原数据一致
+	msg, err = EventCodecFor("").Marshal(&EventData{RID: 2, URI: "/file", Header: http.Header{"X-A": {"1"}}, Body: binary})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"rid":2,"uri":"/file","header":{"X-A":["1"],"X-Event-Body-Encoding":["base64"]},"body":"AP8QgA=="}`; string(msg) != want {
+		t.Errorf("json = %s, want %s", msg, want)
+	}
+	var data EventData
+	if err := EventCodecFor("").Unmarshal(msg, &data); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data.Body, binary) || data.Header.Get("X-A") != "1" || data.Header.Get(HeaderEventBodyEncoding) != "" {
+		t.Errorf("json decode = %+v", data)
+	}
+}
+
+func TestWebSocketSubprotocol(t *testing.T) {
+	s := New()
+	s.WebSocket("/bin", func(c Context) error {
+		return c.Blob(http.StatusOK, "application/octet-stream", []byte{0x00, 0xff})
+	})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{Subprotocols: WebSocketSubprotocols()})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = s.Event(MethodWebSocket, "/ws").Serve(r.Context(), 1, WebSocketWrapper(r.Context(), conn))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 2*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+srv.URL[len("http"):], &websocket.DialOptions{
+		Subprotocols: []string{SubprotocolMsgpack},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+	if conn.Subprotocol() != SubprotocolMsgpack {
+		t.Fatalf("subprotocol = %s", conn.Subprotocol())
+	}
+
+	codec := EventCodecFor(conn.Subprotocol())
+	msg, _ := codec.Marshal(&EventData{RID: 1, URI: "/bin", Header: http.Header{}})
+	if err := conn.Write(ctx, websocket.MessageBinary, msg); err != nil {
+		t.Fatal(err)
+	}
+	typ, msg, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var resp EventData
+	if err := codec.Unmarshal(msg, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if typ != websocket.MessageBinary || resp.RID != 1 || !bytes.Equal(resp.Body, []byte{0x00, 0xff}) {
+		t.Errorf("resp = %v %+v", typ, resp)
+	}
+}
@@ -0,0 +1,179 @@
+package server
+
+import (
+	stdContext "context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrEventSlowConsumer = errors.New("send queue is full")
+	ErrEventConnClosed   = errors.New("connection closed")
+)
+
+const (
+	defaultEventWorkers       = 16
+	defaultEventSendQueueSize = 256
+)
+
+// SlowConsumerPolicy 发送队列达到高水位时的处理策略
+type SlowConsumerPolicy int
+
+const (
+	// SlowConsumerDisconnect 断开连接
+	SlowConsumerDisconnect SlowConsumerPolicy = iota
+	// SlowConsumerDrop 丢弃当前消息，保留连接
+	SlowConsumerDrop
+)
+
+// EventConfig 连接的并发和发送队列配置
+type EventConfig struct {
+	// Workers 每个连接同时处理的请求数，达到上限时暂停读取，默认16
+	Workers int
+	// SendQueueSize 每个连接的发送队列长度，即高水位，默认256
+	SendQueueSize int
+	// SlowConsumer 发送队列已满时的处理策略，默认断开连接
+	SlowConsumer SlowConsumerPolicy
+}
+
+// EventStats 连接和发送队列统计
+type EventStats struct {
+	// Connections 当前节点的连接数
+	Connections int `json:"connections"`
+	// QueueDepth 所有连接发送队列中等待发送的消息数
+	QueueDepth int `json:"queue_depth"`
+	// Inflight 正在处理的请求数
+	Inflight int64 `json:"inflight"`
+	// Sent 已发送的消息数
+	Sent uint64 `json:"sent"`
+	// Dropped 因发送队列已满被丢弃的消息数
+	Dropped uint64 `json:"dropped"`
+	// SlowConsumers 因发送队列已满被断开的连接数
+	SlowConsumers uint64 `json:"slow_consumers"`
+}
+
+type eventCounters struct {
+	inflight      atomic.Int64
+	sent          atomic.Uint64
+	dropped       atomic.Uint64
+	slowConsumers atomic.Uint64
+}
+
+// SetConfig 设置连接的并发和发送队列配置，只对之后建立的连接生效
+func (e *Event) SetConfig(conf EventConfig) {
+	if conf.Workers <= 0 {
+		conf.Workers = defaultEventWorkers
+	}
+	if conf.SendQueueSize <= 0 {
+		conf.SendQueueSize = defaultEventSendQueueSize
+	}
+	e.mu.Lock()
+	e.conf = conf
+	e.mu.Unlock()
+}
+
+// Stats 获取当前节点的连接和发送队列统计
+func (e *Event) Stats() EventStats {
+	e.mu.RLock()
+	stats := EventStats{Connections: len(e.src)}
+	for _, src := range e.src {
+		if conn, ok := src.(*eventConn); ok {
+			stats.QueueDepth += len(conn.queue)
+		}
+	}
+	e.mu.RUnlock()
+	stats.Inflight = e.counters.inflight.Load()
+	stats.Sent = e.counters.sent.Load()
+	stats.Dropped = e.counters.dropped.Load()
+	stats.SlowConsumers = e.counters.slowConsumers.Load()
+	return stats
+}
+
+// eventConn 为连接增加发送队列，所有发送由单独的goroutine按顺序写出，
+// 避免慢客户端阻塞广播和请求处理
+type eventConn struct {
+	SendReceiveCloser
+	counters *eventCounters
+	policy   SlowConsumerPolicy
+	queue    chan *EventData
+	done     chan struct{}
+	once     sync.Once
+	cancel   stdContext.CancelFunc
+}
+
+func newEventConn(src SendReceiveCloser, counters *eventCounters, conf EventConfig, cancel stdContext.CancelFunc) *eventConn {
+	return &eventConn{
+		SendReceiveCloser: src,
+		counters:          counters,
+		policy:            conf.SlowConsumer,
+		queue:             make(chan *EventData, conf.SendQueueSize),
+		done:              make(chan struct{}),
+		cancel:            cancel,
+	}
+}
+
+// Send 将数据放入发送队列，队列已满时按策略丢弃或断开连接
+func (c *eventConn) Send(data *EventData) error {
+	select {
+	case <-c.done:
+		return ErrEventConnClosed
+	default:
+	}
+	select {
+	case c.queue <- data:
+		return nil
+	default:
+	}
+	c.counters.dropped.Add(1)
+	if c.policy == SlowConsumerDisconnect {
+		c.counters.slowConsumers.Add(1)
+		c.shutdown()
+	}
+	return ErrEventSlowConsumer
+}
+
+// writeLoop 按顺序写出发送队列中的数据，写入失败时断开连接
+// replay 不为nil时先发送其返回的历史消息，并跳过队列中id已补发的消息，
+// 读取历史消息失败时断开连接，由客户端重连后重试
+func (c *eventConn) writeLoop(replay func() ([]*EventData, error)) {
+	var replayed uint64
+	if replay != nil {
+		list, err := replay()
+		if err != nil {
+			c.shutdown()
+			return
+		}
+		for _, data := range list {
+			if err := c.SendReceiveCloser.Send(data); err != nil {
+				c.shutdown()
+				return
+			}
+			c.counters.sent.Add(1)
+			replayed = max(replayed, data.ID)
+		}
+	}
+	for {
+		select {
+		case <-c.done:
+			return
+		case data := <-c.queue:
+			if data.ID > 0 && data.ID <= replayed {
+				continue
+			}
+			if err := c.SendReceiveCloser.Send(data); err != nil {
+				c.shutdown()
+				return
+			}
+			c.counters.sent.Add(1)
+		}
+	}
+}
+
+// shutdown 停止发送并结束连接的读取循环
+func (c *eventConn) shutdown() {
+	c.once.Do(func() {
+		close(c.done)
+		c.cancel()
+	})
+}
@@ -0,0 +1,139 @@
+package server
+
+import (
+	stdContext "context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+// blockingBridge 不读取数据的慢客户端
+type blockingBridge struct {
+	*testBridge
+	release chan struct{}
+}
+
+func (b *blockingBridge) Send(data *EventData) error {
+	<-b.release
+	return nil
+}
+
+func TestEventSlowConsumer(t *testing.T) {
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+
+	e := New().Event(MethodWebSocket, "/ws")
+	e.SetConfig(EventConfig{SendQueueSize: 1})
+	client := &blockingBridge{testBridge: newTestBridge(), release: make(chan struct{})}
+	defer close(client.release)
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Serve(ctx, 1, client)
+	}()
+	for i := 0; e.Stats().Connections == 0; i++ {
+		if i > 100 {
+			t.Fatal("client not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// 第一条消息阻塞在写入，第二条进入队列，之后队列已满
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = e.broadcastLocal(&EventData{URI: "/msg"})
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err == nil {
+		t.Fatal("expect slow consumer error")
+	}
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("slow consumer not disconnected")
+	}
+	stats := e.Stats()
+	if stats.Connections != 0 || stats.SlowConsumers != 1 || stats.Dropped != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestWebSocketPongTimeout(t *testing.T) {
+	closed := make(chan error, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		b := NewWebSocketBridge(r.Context(), conn, WebSocketConfig{
+			PingInterval: 20 * time.Millisecond,
+			PongTimeout:  20 * time.Millisecond,
+		})
+		_, err = b.Receive(r.Context())
+		closed <- err
+	}))
+	defer srv.Close()
+
+	// 客户端不读取数据，ping帧得不到响应
+	conn, _, err := websocket.Dial(stdContext.Background(), "ws"+srv.URL[len("http"):], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+
+	select {
+	case err := <-closed:
+		if err == nil {
+			t.Error("expect receive error after pong timeout")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("connection not closed after pong timeout")
+	}
+}
+
+func TestWebSocketBusyKeepalive(t *testing.T) {
+	s := New()
+	s.WebSocket("/slow", func(c Context) error {
+		time.Sleep(150 * time.Millisecond)
+		return c.Blob(http.StatusOK, MIMEApplicationJSON, []byte(`"ok"`))
+	})
+	e := s.Event(MethodWebSocket, "/ws")
+	e.SetConfig(EventConfig{Workers: 1})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		_ = e.Serve(r.Context(), 1, NewWebSocketBridge(r.Context(), conn, WebSocketConfig{
+			PingInterval: 20 * time.Millisecond,
+			PongTimeout:  20 * time.Millisecond,
+		}))
+	}))
+	defer srv.Close()
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+srv.URL[len("http"):], nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+
+	// 处理数达到上限时暂停读取，期间不能因收不到pong而断开连接
+	for i := 1; i <= 3; i++ {
+		msg := []byte(`{"rid":` + strconv.Itoa(i) + `,"uri":"/slow","body":null}`)
+		if err := conn.Write(ctx, websocket.MessageText, msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, _, err := conn.Read(ctx); err != nil {
+			t.Fatalf("response %d: %v", i+1, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	stdContext "context"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+const (
+	// HeaderLastEventID 客户端重连时携带最后收到的消息id
+	HeaderLastEventID = "Last-Event-ID"
+
+	defaultEventLogSize = 1000
+)
+
+// EventLog 广播消息日志，用于客户端断线重连后补发错过的消息
+type EventLog interface {
+	// Append 追加消息，返回分配的递增id
+	Append(ctx stdContext.Context, key string, data *EventData) (uint64, error)
+	// Since 按id升序返回id大于 lastID 的消息，超出保留范围的消息不再返回
+	Since(ctx stdContext.Context, key string, lastID uint64) ([]*EventData, error)
+}
+
+// SetEventLog 设置广播消息日志，设置后 Broadcast 的消息会分配id并写入日志，
+// 客户端可通过 ServeFrom 从最后收到的id恢复
+func (e *Event) SetEventLog(log EventLog) {
+	e.mu.Lock()
+	e.log = log
+	e.mu.Unlock()
+}
+
+func (e *Event) logKey() string {
+	return e.method + ":" + e.subject
+}
+
+// LastEventID 获取客户端最后收到的消息id，依次读取 Last-Event-ID 请求头和 last_event_id 参数，
+// 浏览器的WebSocket无法设置请求头，可以使用参数
+func LastEventID(r *http.Request) uint64 {
+	val := r.Header.Get(HeaderLastEventID)
+	if val == "" {
+		val = r.URL.Query().Get("last_event_id")
+	}
+	id, _ := strconv.ParseUint(val, 10, 64)
+	return id
+}
+
+// memoryEventLog 进程内的消息日志，每个key保留最近的size条消息
+type memoryEventLog struct {
+	mu   sync.Mutex
+	size int
+	logs map[string]*memoryEventRing
+}
+
+type memoryEventRing struct {
+	last  uint64
+	items []*EventData
+}
+
+// NewMemoryEventLog 创建进程内的消息日志，size 为每个key保留的消息数，默认1000
+// 多个节点之间不共享，启用集群时应使用 NewRedisEventLog
+func NewMemoryEventLog(size int) EventLog {
+	if size <= 0 {
+		size = defaultEventLogSize
+	}
+	return &memoryEventLog{size: size, logs: make(map[string]*memoryEventRing)}
+}
+
+func (l *memoryEventLog) Append(ctx stdContext.Context, key string, data *EventData) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ring, ok := l.logs[key]
+	if !ok {
+		ring = &memoryEventRing{}
+		l.logs[key] = ring
+	}
+	ring.last++
+	item := *data
+	item.ID = ring.last
+	ring.items = append(ring.items, &item)
+	if len(ring.items) > l.size {
+		ring.items[0] = nil
+		ring.items = ring.items[1:]
+	}
+	return item.ID, nil
+}
+
+func (l *memoryEventLog) Since(ctx stdContext.Context, key string, lastID uint64) ([]*EventData, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	ring, ok := l.logs[key]
+	if !ok {
+		return nil, nil
+	}
+	var list []*EventData
+	for _, item := range ring.items {
+		if item.ID > lastID {
+			list = append(list, item)
+		}
+	}
+	return list, nil
+}
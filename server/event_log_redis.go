@@ -0,0 +1,74 @@
+package server
+
+import (
+	stdContext "context"
+	"strconv"
+	"strings"
+
+	"github.com/lazygo/lazygo/redis"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// appendEventScript 递增id并以 id-0 作为stream id写入，保证id与写入顺序一致
+const appendEventScript = `
+	local id = redis.call("INCR", KEYS[1])
+	redis.call("XADD", KEYS[2], "MAXLEN", "~", ARGV[2], id .. "-0", "data", ARGV[1])
+	return id
+`
+
+// redisEventLog 基于 redis stream 的消息日志，多个节点共享id和消息
+type redisEventLog struct {
+	prefix string
+	maxLen int64
+	conn   *goredis.Client
+	script *goredis.Script
+}
+
+// NewRedisEventLog 创建基于 redis stream 的消息日志
+// name 为 redis.Init 中配置的redis名称，prefix 为key的前缀，maxLen 为每个key大约保留的消息数，默认1000
+func NewRedisEventLog(name, prefix string, maxLen int64) (EventLog, error) {
+	conn, err := redis.Client(name)
+	if err != nil {
+		return nil, err
+	}
+	if maxLen <= 0 {
+		maxLen = defaultEventLogSize
+	}
+	return &redisEventLog{
+		prefix: prefix,
+		maxLen: maxLen,
+		conn:   conn,
+		script: goredis.NewScript(appendEventScript),
+	}, nil
+}
+
+func (l *redisEventLog) Append(ctx stdContext.Context, key string, data *EventData) (uint64, error) {
+	// 使用msgpack保存，body可以是任意字节
+	msg, err := msgpackCodec{}.Marshal(data)
+	if err != nil {
+		return 0, err
+	}
+	keys := []string{l.prefix + "lazygo:event:log:id:" + key, l.prefix + "lazygo:event:log:" + key}
+	return l.script.Run(ctx, l.conn, keys, msg, l.maxLen).Uint64()
+}
+
+func (l *redisEventLog) Since(ctx stdContext.Context, key string, lastID uint64) ([]*EventData, error) {
+	// 写入的stream id均为 id-0，从 lastID-1 开始即不包含 lastID
+	start := strconv.FormatUint(lastID, 10) + "-1"
+	msgs, err := l.conn.XRange(ctx, l.prefix+"lazygo:event:log:"+key, start, "+").Result()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*EventData, 0, len(msgs))
+	for _, msg := range msgs {
+		raw, _ := msg.Values["data"].(string)
+		var data EventData
+		if err := (msgpackCodec{}).Unmarshal([]byte(raw), &data); err != nil {
+			continue
+		}
+		id, _, _ := strings.Cut(msg.ID, "-")
+		data.ID, _ = strconv.ParseUint(id, 10, 64)
+		list = append(list, &data)
+	}
+	return list, nil
+}
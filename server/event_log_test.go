@@ -0,0 +1,41 @@
+package server
+
+import (
+	stdContext "context"
+	"testing"
+	"time"
+)
+
+func TestEventLogReplay(t *testing.T) {
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+
+	e := New().Event(MethodWebSocket, "/ws")
+	e.SetEventLog(NewMemoryEventLog(2))
+	for _, uri := range []string{"/1", "/2", "/3"} {
+		if err := e.Broadcast(ctx, &EventData{URI: uri}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// 只保留最近2条，从id 1恢复时补发 2、3
+	client := newTestBridge()
+	go e.ServeFrom(ctx, 1, client, 1)
+	for _, want := range []uint64{2, 3} {
+		if data := client.next(t); data.ID != want {
+			t.Errorf("replay id = %d, want %d", data.ID, want)
+		}
+	}
+	for i := 0; e.Stats().Connections == 0; i++ {
+		if i > 100 {
+			t.Fatal("client not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := e.Broadcast(ctx, &EventData{URI: "/4"}); err != nil {
+		t.Fatal(err)
+	}
+	if data := client.next(t); data.ID != 4 || data.URI != "/4" {
+		t.Errorf("live = %d %s, want 4 /4", data.ID, data.URI)
+	}
+}
@@ -0,0 +1,294 @@
+package server
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+// 客户端通过以下URI加入或离开房间，body为 {"room": "房间名"}
+const (
+	EventURIJoin     = "/_event/join"
+	EventURILeave    = "/_event/leave"
+	EventURIPresence = "/_event/presence"
+)
+
+var ErrEventRoomRequired = errors.New("room is required")
+
+// eventRoomState 房间、成员和连接元数据
+type eventRoomState struct {
+	// rooms 房间 => cid集合
+	rooms map[string]map[uint64]struct{}
+	// joined cid => 已加入的房间
+	joined map[uint64]map[string]struct{}
+	// meta cid => 元数据，如uid
+	meta     map[uint64]map[string]string
+	presence bool
+	onJoin   func(cid uint64, room string) error
+}
+
+type eventRoomBody struct {
+	Room string `json:"room"`
+}
+
+// EventPresence 成员加入或离开房间时发送给房间成员的通知
+type EventPresence struct {
+	Action string            `json:"action"`
+	Room   string            `json:"room"`
+	CID    uint64            `json:"cid"`
+	Meta   map[string]string `json:"meta,omitempty"`
+}
+
+// SetPresence 开启后，成员加入或离开房间时向房间内的其他成员发送 EventURIPresence 通知
+func (e *Event) SetPresence(enable bool) {
+	e.mu.Lock()
+	e.room.presence = enable
+	e.mu.Unlock()
+}
+
+// OnJoin 设置客户端请求加入房间时的校验函数，返回错误时拒绝加入
+func (e *Event) OnJoin(fn func(cid uint64, room string) error) {
+	e.mu.Lock()
+	e.room.onJoin = fn
+	e.mu.Unlock()
+}
+
+// SetMeta 为连接设置元数据，如 uid
+func (e *Event) SetMeta(cid uint64, key, val string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if _, ok := e.src[cid]; !ok {
+		return
+	}
+	if e.room.meta[cid] == nil {
+		e.room.meta[cid] = make(map[string]string)
+	}
+	e.room.meta[cid][key] = val
+}
+
+// Meta 获取连接的元数据
+func (e *Event) Meta(cid uint64) map[string]string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return maps.Clone(e.room.meta[cid])
+}
+
+// Find 获取当前节点上元数据 key 等于 val 的所有连接，如 uid=42 的所有连接
+func (e *Event) Find(key, val string) []uint64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var list []uint64
+	for cid, meta := range e.room.meta {
+		if v, ok := meta[key]; ok && v == val {
+			list = append(list, cid)
+		}
+	}
+	slices.Sort(list)
+	return list
+}
+
+// SendWhere 向元数据 key 等于 val 的所有连接发送数据，启用集群时同时转发到其他节点
+func (e *Event) SendWhere(ctx stdContext.Context, key, val string, data *EventData) error {
+	err := e.sendLocal(e.Find(key, val), data)
+	if c := e.server.eventManager.cluster; c != nil {
+		perr := c.publish(ctx, clusterBroadcastChannel, &clusterMessage{
+			Type:    clusterMsgSendWhere,
+			Method:  e.method,
+			Subject: e.subject,
+			Key:     key,
+			Value:   val,
+			Data:    data,
+		})
+		if perr != nil {
+			err = errors.Join(err, perr)
+		}
+	}
+	return err
+}
+
+// Join 将连接加入房间
+func (e *Event) Join(cid uint64, room string) error {
+	if room == "" {
+		return ErrEventRoomRequired
+	}
+	e.mu.Lock()
+	if _, ok := e.src[cid]; !ok {
+		e.mu.Unlock()
+		return ErrEventCIDNotExists
+	}
+	if _, ok := e.room.rooms[room][cid]; ok {
+		e.mu.Unlock()
+		return nil
+	}
+	if e.room.rooms[room] == nil {
+		e.room.rooms[room] = make(map[uint64]struct{})
+	}
+	e.room.rooms[room][cid] = struct{}{}
+	if e.room.joined[cid] == nil {
+		e.room.joined[cid] = make(map[string]struct{})
+	}
+	e.room.joined[cid][room] = struct{}{}
+	presence := e.room.presence
+	meta := maps.Clone(e.room.meta[cid])
+	e.mu.Unlock()
+
+	if presence {
+		e.notifyPresence("join", room, cid, meta)
+	}
+	return nil
+}
+
+// Leave 将连接移出房间
+func (e *Event) Leave(cid uint64, room string) {
+	e.mu.Lock()
+	if _, ok := e.room.rooms[room][cid]; !ok {
+		e.mu.Unlock()
+		return
+	}
+	e.removeMember(cid, room)
+	presence := e.room.presence
+	meta := maps.Clone(e.room.meta[cid])
+	e.mu.Unlock()
+
+	if presence {
+		e.notifyPresence("leave", room, cid, meta)
+	}
+}
+
+// Members 获取当前节点上房间内的所有连接
+func (e *Event) Members(room string) []uint64 {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	list := slices.Collect(maps.Keys(e.room.rooms[room]))
+	slices.Sort(list)
+	return list
+}
+
+// Publish 向房间内的所有连接发送数据，启用集群时同时转发到其他节点
+func (e *Event) Publish(ctx stdContext.Context, room string, data *EventData) error {
+	return e.publish(ctx, room, data)
+}
+
+// publish 向房间内除 except 以外的连接发送数据
+func (e *Event) publish(ctx stdContext.Context, room string, data *EventData, except ...uint64) error {
+	cids := slices.DeleteFunc(e.Members(room), func(cid uint64) bool {
+		return slices.Contains(except, cid)
+	})
+	err := e.sendLocal(cids, data)
+	if c := e.server.eventManager.cluster; c != nil {
+		perr := c.publish(ctx, clusterBroadcastChannel, &clusterMessage{
+			Type:    clusterMsgPublish,
+			Method:  e.method,
+			Subject: e.subject,
+			Room:    room,
+			Data:    data,
+		})
+		if perr != nil {
+			err = errors.Join(err, perr)
+		}
+	}
+	return err
+}
+
+// sendLocal 向当前节点的指定连接发送数据
+func (e *Event) sendLocal(cids []uint64, data *EventData) error {
+	var list []SendReceiveCloser
+	e.mu.RLock()
+	for _, cid := range cids {
+		if src, ok := e.src[cid]; ok {
+			list = append(list, src)
+		}
+	}
+	e.mu.RUnlock()
+
+	var errs error
+	for _, src := range list {
+		if err := src.Send(data); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	return errs
+}
+
+// removeMember 调用方需持有写锁
+func (e *Event) removeMember(cid uint64, room string) {
+	delete(e.room.rooms[room], cid)
+	if len(e.room.rooms[room]) == 0 {
+		delete(e.room.rooms, room)
+	}
+	delete(e.room.joined[cid], room)
+	if len(e.room.joined[cid]) == 0 {
+		delete(e.room.joined, cid)
+	}
+}
+
+// leaveAll 连接断开时离开所有房间并清除元数据
+func (e *Event) leaveAll(cid uint64) {
+	e.mu.Lock()
+	rooms := slices.Collect(maps.Keys(e.room.joined[cid]))
+	for _, room := range rooms {
+		e.removeMember(cid, room)
+	}
+	presence := e.room.presence
+	meta := e.room.meta[cid]
+	delete(e.room.meta, cid)
+	e.mu.Unlock()
+
+	if presence {
+		for _, room := range rooms {
+			e.notifyPresence("leave", room, cid, meta)
+		}
+	}
+}
+
+func (e *Event) notifyPresence(action, room string, cid uint64, meta map[string]string) {
+	body, err := json.Marshal(&EventPresence{Action: action, Room: room, CID: cid, Meta: meta})
+	if err != nil {
+		return
+	}
+	// 连接只在当前节点，其他节点无需排除
+	_ = e.publish(stdContext.Background(), room, &EventData{
+		URI:    EventURIPresence,
+		Header: http.Header{},
+		Body:   body,
+	}, cid)
+}
+
+// handleRoom 处理客户端加入或离开房间的请求
+func (e *Event) handleRoom(cid uint64, src SendReceiveCloser, req *EventData) {
+	var body eventRoomBody
+	err := json.Unmarshal(req.Body, &body)
+	if err == nil && body.Room == "" {
+		err = ErrEventRoomRequired
+	}
+	if err == nil {
+		if req.URI == EventURIJoin {
+			e.mu.RLock()
+			onJoin := e.room.onJoin
+			e.mu.RUnlock()
+			if onJoin != nil {
+				err = onJoin(cid, body.Room)
+			}
+			if err == nil {
+				err = e.Join(cid, body.Room)
+			}
+		} else {
+			e.Leave(cid, body.Room)
+		}
+	}
+
+	resp := Map{"room": body.Room}
+	if err != nil {
+		resp["error"] = err.Error()
+	}
+	b, _ := json.Marshal(resp)
+	_ = src.Send(&EventData{
+		RID:    req.RID,
+		URI:    req.URI,
+		Header: http.Header{},
+		Body:   b,
+	})
+}
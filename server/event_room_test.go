@@ -0,0 +1,92 @@
+package server
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestEventRoom(t *testing.T) {
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+
+	e := New().Event(MethodWebSocket, "/ws")
+	e.SetPresence(true)
+	c1, c2 := newTestBridge(), newTestBridge()
+	ctx1, cancel1 := stdContext.WithCancel(ctx)
+	done1 := make(chan struct{})
+	go func() {
+		_ = e.Serve(ctx1, 1, c1)
+		close(done1)
+	}()
+	go e.Serve(ctx, 2, c2)
+	for i := 0; ; i++ {
+		e.mu.RLock()
+		n := len(e.src)
+		e.mu.RUnlock()
+		if n == 2 {
+			break
+		}
+		if i > 100 {
+			t.Fatal("clients not registered")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	e.SetMeta(1, "uid", "42")
+	if got := e.Find("uid", "42"); !slices.Equal(got, []uint64{1}) {
+		t.Errorf("Find() = %v, want [1]", got)
+	}
+
+	// 客户端请求加入房间
+	c1.in <- &EventData{RID: 1, URI: EventURIJoin, Body: json.RawMessage(`{"room":"r1"}`)}
+	if ack := c1.next(t); ack.RID != 1 || string(ack.Body) != `{"room":"r1"}` {
+		t.Errorf("join ack = %d %s", ack.RID, ack.Body)
+	}
+	c2.in <- &EventData{RID: 2, URI: EventURIJoin, Body: json.RawMessage(`{"room":"r1"}`)}
+	// c1 收到 c2 加入的通知
+	var presence EventPresence
+	if data := c1.next(t); data.URI != EventURIPresence {
+		t.Fatalf("presence uri = %s", data.URI)
+	} else if err := json.Unmarshal(data.Body, &presence); err != nil || presence.Action != "join" || presence.CID != 2 {
+		t.Errorf("presence = %+v, %v", presence, err)
+	}
+	c2.next(t)
+	if got := e.Members("r1"); !slices.Equal(got, []uint64{1, 2}) {
+		t.Errorf("Members() = %v, want [1 2]", got)
+	}
+
+	if err := e.Publish(ctx, "r1", &EventData{URI: "/msg"}); err != nil {
+		t.Fatal(err)
+	}
+	if data := c1.next(t); data.URI != "/msg" {
+		t.Errorf("c1 uri = %s, want /msg", data.URI)
+	}
+	if data := c2.next(t); data.URI != "/msg" {
+		t.Errorf("c2 uri = %s, want /msg", data.URI)
+	}
+
+	if err := e.SendWhere(ctx, "uid", "42", &EventData{URI: "/user"}); err != nil {
+		t.Fatal(err)
+	}
+	if data := c1.next(t); data.URI != "/user" {
+		t.Errorf("c1 uri = %s, want /user", data.URI)
+	}
+
+	// 断开连接后离开所有房间
+	cancel1()
+	<-done1
+	if data := c2.next(t); data.URI != EventURIPresence {
+		t.Errorf("presence uri = %s", data.URI)
+	} else if err := json.Unmarshal(data.Body, &presence); err != nil || presence.Action != "leave" || presence.Meta["uid"] != "42" {
+		t.Errorf("presence = %+v, %v", presence, err)
+	}
+	if got := e.Members("r1"); !slices.Equal(got, []uint64{2}) {
+		t.Errorf("Members() = %v, want [2]", got)
+	}
+	if got := e.Find("uid", "42"); len(got) != 0 {
+		t.Errorf("Find() = %v, want empty", got)
+	}
+}
@@ -0,0 +1,406 @@
+package server
+
+// 流式导出CSV和NDJSON，逐行从迭代器读取数据并写入响应，内存占用与数据量无关
+
+import (
+	"bufio"
+	"database/sql/driver"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"mime"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultExportFlushRows     = 1000
+	defaultExportFlushInterval = time.Second
+)
+
+var ErrExportColumn = errors.New("export column not found")
+
+// ExportConfig 导出配置
+type ExportConfig struct {
+	// Columns 导出的列，为结构体字段的json tag或map的key，按顺序输出，默认所有字段
+	// map 类型的行默认按key排序输出
+	Columns []string
+	// Filename 下载文件名，设置时以附件形式下载
+	Filename string
+	// NoHeader CSV不输出表头，表头默认为字段的csv tag，没有csv tag时为json tag
+	NoHeader bool
+	// BOM CSV输出UTF-8 BOM，使Excel正确识别中文
+	BOM bool
+	// FlushRows 每写入多少行刷新一次响应，默认1000
+	FlushRows int
+	// FlushInterval 距离上次刷新超过该时间时刷新响应，默认1秒
+	FlushInterval time.Duration
+}
+
+// Rows 将类型化的迭代器转换为 Context.CSV 和 Context.NDJSON 使用的迭代器，如
+//
+//	c.CSV(http.StatusOK, server.Rows(mdlUser.Iterate(cond)), server.ExportConfig{Filename: "users.csv"})
+func Rows[T any](seq iter.Seq2[T, error]) iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		for row, err := range seq {
+			if !yield(row, err) {
+				return
+			}
+		}
+	}
+}
+
+// exportField 结构体导出字段
+type exportField struct {
+	name   string
+	header string
+	index  []int
+}
+
+// exportPlan 按第一行数据确定的列
+type exportPlan struct {
+	columns []string
+	headers []string
+	values  func(rv reflect.Value) []reflect.Value
+}
+
+var exportFieldsCache sync.Map
+
+// exportFields 结构体的导出字段，嵌入的结构体没有json tag时展开
+func exportFields(rt reflect.Type) []exportField {
+	if v, ok := exportFieldsCache.Load(rt); ok {
+		return v.([]exportField)
+	}
+	var fields []exportField
+	var walk func(rt reflect.Type, index []int)
+	walk = func(rt reflect.Type, index []int) {
+		for i := 0; i < rt.NumField(); i++ {
+			f := rt.Field(i)
+			idx := append(slices.Clone(index), i)
+			name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				walk(ft, idx)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			header := f.Tag.Get("csv")
+			if header == "-" {
+				continue
+			}
+			if header == "" {
+				header = name
+			}
+			fields = append(fields, exportField{name: name, header: header, index: idx})
+		}
+	}
+	walk(rt, nil)
+	exportFieldsCache.Store(rt, fields)
+	return fields
+}
+
+func newExportPlan(row any, columns []string) (*exportPlan, error) {
+	rv := reflect.Indirect(reflect.ValueOf(row))
+	switch rv.Kind() {
+	case reflect.Struct:
+		fields := exportFields(rv.Type())
+		if len(columns) > 0 {
+			selected := make([]exportField, 0, len(columns))
+			for _, col := range columns {
+				i := slices.IndexFunc(fields, func(f exportField) bool { return f.name == col })
+				if i < 0 {
+					return nil, fmt.Errorf("%w: %s", ErrExportColumn, col)
+				}
+				selected = append(selected, fields[i])
+			}
+			fields = selected
+		}
+		plan := &exportPlan{}
+		for _, f := range fields {
+			plan.columns = append(plan.columns, f.name)
+			plan.headers = append(plan.headers, f.header)
+		}
+		plan.values = func(rv reflect.Value) []reflect.Value {
+			rv = reflect.Indirect(rv)
+			values := make([]reflect.Value, len(fields))
+			for i, f := range fields {
+				// 嵌入的结构体指针为nil时值为空
+				values[i], _ = rv.FieldByIndexErr(f.index)
+			}
+			return values
+		}
+		return plan, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("export: unsupported row type %s", rv.Type())
+		}
+		if len(columns) == 0 {
+			for _, k := range rv.MapKeys() {
+				columns = append(columns, k.String())
+			}
+			slices.Sort(columns)
+		}
+		keys := make([]reflect.Value, len(columns))
+		for i, col := range columns {
+			keys[i] = reflect.ValueOf(col).Convert(rv.Type().Key())
+		}
+		plan := &exportPlan{columns: columns, headers: columns}
+		plan.values = func(rv reflect.Value) []reflect.Value {
+			rv = reflect.Indirect(rv)
+			values := make([]reflect.Value, len(keys))
+			for i, k := range keys {
+				values[i] = rv.MapIndex(k)
+			}
+			return values
+		}
+		return plan, nil
+	}
+	return nil, fmt.Errorf("export: unsupported row type %T", row)
+}
+
+// exportValue 导出值，driver.Valuer（如 sql.Null[T]）使用 Value 的结果，nil 为空
+func exportValue(v reflect.Value) any {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil
+	}
+	if valuer, ok := v.Interface().(driver.Valuer); ok {
+		val, err := valuer.Value()
+		if err != nil {
+			return nil
+		}
+		return val
+	}
+	return v.Interface()
+}
+
+// csvValue CSV单元格的值
+func csvValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case fmt.Stringer:
+		return v.String()
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// exportWriter 延迟到第一行数据或结束时才输出响应头，使查询错误仍能返回错误响应
+type exportWriter struct {
+	c         *context
+	code      int
+	mimeType  string
+	conf      ExportConfig
+	buf       *bufio.Writer
+	started   bool
+	rows      int
+	lastFlush time.Time
+}
+
+func newExportWriter(c *context, code int, mimeType string, conf []ExportConfig) *exportWriter {
+	w := &exportWriter{c: c, code: code, mimeType: mimeType}
+	if len(conf) > 0 {
+		w.conf = conf[0]
+	}
+	if w.conf.FlushRows <= 0 {
+		w.conf.FlushRows = defaultExportFlushRows
+	}
+	if w.conf.FlushInterval <= 0 {
+		w.conf.FlushInterval = defaultExportFlushInterval
+	}
+	return w
+}
+
+func (w *exportWriter) start() {
+	if w.started {
+		return
+	}
+	w.started = true
+	header := w.c.responseWriter.Header()
+	header.Set(HeaderContentType, w.mimeType)
+	if w.conf.Filename != "" {
+		header.Set(HeaderContentDisposition, mime.FormatMediaType("attachment", map[string]string{"filename": w.conf.Filename}))
+	}
+	// 禁止反向代理缓冲，如 nginx
+	header.Set("X-Accel-Buffering", "no")
+	w.c.responseWriter.WriteHeader(w.code)
+	w.buf = bufio.NewWriter(w.c.responseWriter)
+	w.lastFlush = time.Now()
+	if w.conf.BOM && w.mimeType == MIMETextCSVCharsetUTF8 {
+		_, _ = w.buf.WriteString("\uFEFF")
+	}
+}
+
+// row 每写入一行调用，按行数或时间间隔刷新
+func (w *exportWriter) row() error {
+	w.rows++
+	if w.rows%w.conf.FlushRows == 0 || time.Since(w.lastFlush) >= w.conf.FlushInterval {
+		return w.flush()
+	}
+	return nil
+}
+
+func (w *exportWriter) flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return err
+	}
+	w.c.responseWriter.Flush()
+	w.lastFlush = time.Now()
+	return nil
+}
+
+// each 遍历所有行，客户端断开连接时停止
+func (w *exportWriter) each(rows iter.Seq2[any, error], columns []string, fn func(plan *exportPlan, rv reflect.Value) error) error {
+	ctx := w.c.Request().Context()
+	var plan *exportPlan
+	for row, err := range rows {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if plan == nil {
+			if plan, err = newExportPlan(row, columns); err != nil {
+				return err
+			}
+		}
+		if err := fn(plan, reflect.ValueOf(row)); err != nil {
+			return err
+		}
+		if err := w.row(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *context) CSV(code int, rows iter.Seq2[any, error], conf ...ExportConfig) error {
+	w := newExportWriter(c, code, MIMETextCSVCharsetUTF8, conf)
+	var cw *csv.Writer
+	record := []string{}
+	err := w.each(rows, w.conf.Columns, func(plan *exportPlan, rv reflect.Value) error {
+		if cw == nil {
+			w.start()
+			cw = csv.NewWriter(w.buf)
+			if !w.conf.NoHeader {
+				if err := cw.Write(plan.headers); err != nil {
+					return err
+				}
+			}
+		}
+		record = record[:0]
+		for _, v := range plan.values(rv) {
+			record = append(record, csvValue(exportValue(v)))
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+		// csv.Writer 自带缓冲，先写入 bufio 以便按需刷新
+		cw.Flush()
+		return cw.Error()
+	})
+	if err != nil {
+		if w.started {
+			_ = w.flush()
+		}
+		return err
+	}
+	if !w.started {
+		// 没有数据时只输出表头，列为 Columns
+		w.start()
+		if !w.conf.NoHeader && len(w.conf.Columns) > 0 {
+			cw = csv.NewWriter(w.buf)
+			_ = cw.Write(w.conf.Columns)
+			cw.Flush()
+		}
+	}
+	return w.flush()
+}
+
+func (c *context) NDJSON(code int, rows iter.Seq2[any, error], conf ...ExportConfig) error {
+	w := newExportWriter(c, code, MIMEApplicationNDJSON, conf)
+	err := w.each(rows, w.conf.Columns, func(plan *exportPlan, rv reflect.Value) error {
+		w.start()
+		if len(w.conf.Columns) == 0 {
+			// 没有指定列时按json tag完整输出
+			b, err := json.Marshal(rv.Interface())
+			if err != nil {
+				return err
+			}
+			_, _ = w.buf.Write(b)
+			return w.buf.WriteByte('\n')
+		}
+		_ = w.buf.WriteByte('{')
+		for i, v := range plan.values(rv) {
+			if i > 0 {
+				_ = w.buf.WriteByte(',')
+			}
+			writeJSON(w.buf, plan.columns[i])
+			_ = w.buf.WriteByte(':')
+			writeJSON(w.buf, exportValue(v))
+		}
+		_, err := w.buf.WriteString("}\n")
+		return err
+	})
+	if err != nil {
+		if w.started {
+			_ = w.flush()
+		}
+		return err
+	}
+	w.start()
+	return w.flush()
+}
+
+func writeJSON(w io.Writer, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		b = []byte("null")
+	}
+	_, _ = w.Write(b)
+}
@@ -0,0 +1,121 @@
+package server
+
+import (
+	stdContext "context"
+	"database/sql"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type exportBase struct {
+	ID uint64 `json:"id" csv:"编号"`
+}
+
+type exportUser struct {
+	exportBase
+	Name   string           `json:"name" csv:"姓名"`
+	Mobile sql.Null[string] `json:"mobile"`
+	Secret string           `json:"-"`
+}
+
+func exportUsers(n int, fail error) iter.Seq2[exportUser, error] {
+	return func(yield func(exportUser, error) bool) {
+		if fail != nil {
+			yield(exportUser{}, fail)
+			return
+		}
+		for i := 1; i <= n; i++ {
+			u := exportUser{exportBase: exportBase{ID: uint64(i)}, Name: "u,\"" + string(rune('a'+i-1)), Secret: "x"}
+			if i%2 == 0 {
+				u.Mobile = sql.Null[string]{V: "138", Valid: true}
+			}
+			if !yield(u, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	s := New()
+	s.Get("/csv", func(c Context) error {
+		return c.CSV(http.StatusOK, Rows(exportUsers(3, nil)), ExportConfig{Filename: "用户.csv", FlushRows: 2})
+	})
+	s.Get("/csv_columns", func(c Context) error {
+		return c.CSV(http.StatusOK, Rows(exportUsers(2, nil)), ExportConfig{Columns: []string{"mobile", "id"}, NoHeader: true})
+	})
+	s.Get("/ndjson", func(c Context) error {
+		return c.NDJSON(http.StatusOK, Rows(exportUsers(2, nil)))
+	})
+	s.Get("/ndjson_columns", func(c Context) error {
+		return c.NDJSON(http.StatusOK, Rows(exportUsers(2, nil)), ExportConfig{Columns: []string{"name", "mobile"}})
+	})
+	s.Get("/map", func(c Context) error {
+		return c.CSV(http.StatusOK, Rows(func(yield func(map[string]string, error) bool) {
+			yield(map[string]string{"b": "2", "a": "1"}, nil)
+		}))
+	})
+	s.Get("/fail", func(c Context) error {
+		return c.CSV(http.StatusOK, Rows(exportUsers(0, errors.New("query fail"))))
+	})
+	s.Get("/bad_column", func(c Context) error {
+		return c.NDJSON(http.StatusOK, Rows(exportUsers(1, nil)), ExportConfig{Columns: []string{"secret"}})
+	})
+
+	tests := []struct {
+		path  string
+		code  int
+		ctype string
+		want  string
+	}{
+		{"/csv", http.StatusOK, MIMETextCSVCharsetUTF8, "编号,姓名,mobile\n1,\"u,\"\"a\",\n2,\"u,\"\"b\",138\n3,\"u,\"\"c\",\n"},
+		{"/csv_columns", http.StatusOK, MIMETextCSVCharsetUTF8, ",1\n138,2\n"},
+		{"/ndjson", http.StatusOK, MIMEApplicationNDJSON, "{\"id\":1,\"name\":\"u,\\\"a\",\"mobile\":{\"V\":\"\",\"Valid\":false}}\n{\"id\":2,\"name\":\"u,\\\"b\",\"mobile\":{\"V\":\"138\",\"Valid\":true}}\n"},
+		{"/ndjson_columns", http.StatusOK, MIMEApplicationNDJSON, "{\"name\":\"u,\\\"a\",\"mobile\":null}\n{\"name\":\"u,\\\"b\",\"mobile\":\"138\"}\n"},
+		{"/map", http.StatusOK, MIMETextCSVCharsetUTF8, "a,b\n1,2\n"},
+		{"/fail", http.StatusInternalServerError, MIMEApplicationJSONCharsetUTF8, ""},
+		{"/bad_column", http.StatusInternalServerError, MIMEApplicationJSONCharsetUTF8, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code || rec.Header().Get(HeaderContentType) != tt.ctype {
+			t.Errorf("%s: status = %d, content type = %s", tt.path, rec.Code, rec.Header().Get(HeaderContentType))
+			continue
+		}
+		if tt.want != "" && rec.Body.String() != tt.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tt.path, rec.Body.String(), tt.want)
+		}
+		if tt.path == "/csv" && rec.Header().Get(HeaderContentDisposition) != "attachment; filename*=utf-8''%E7%94%A8%E6%88%B7.csv" {
+			t.Errorf("content disposition = %s", rec.Header().Get(HeaderContentDisposition))
+		}
+	}
+}
+
+func TestExportClientDisconnect(t *testing.T) {
+	s := New()
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	var produced int
+	rows := func(yield func(any, error) bool) {
+		for i := 0; ; i++ {
+			produced++
+			if i == 10 {
+				cancel()
+			}
+			if !yield(map[string]int{"i": i}, nil) {
+				return
+			}
+		}
+	}
+	req := httptest.NewRequest(http.MethodGet, "/", nil).WithContext(ctx)
+	c := s.NewContext(req, httptest.NewRecorder())
+	if err := c.NDJSON(http.StatusOK, rows); !errors.Is(err, stdContext.Canceled) {
+		t.Fatalf("err = %v", err)
+	}
+	if produced != 11 {
+		t.Fatalf("produced %d rows after disconnect", produced)
+	}
+}
@@ -1,6 +1,9 @@
 package server
 
-import "strings"
+import (
+	"maps"
+	"strings"
+)
 
 type (
 	// Group is a set of sub-routes for a specified route. It can be used for inner
@@ -10,6 +13,7 @@ type (
 		common
 		prefix     string
 		middleware []MiddlewareFunc
+		meta       Meta
 		server     *Server
 	}
 )
@@ -33,14 +37,14 @@ func (g *Group) Use(middleware ...MiddlewareFunc) {
 }
 
 // Add implements `Server#Add()` for sub-routes within the Group.
-func (g *Group) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+func (g *Group) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) Routes {
 	// Combine into a new slice to avoid accidentally passing the same slice for
 	// multiple routes, which would lead to later add() calls overwriting the
 	// middleware from earlier calls.
 	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	g.server.Add(method, g.concat(g.prefix, path), handler, m...)
+	return g.server.Add(method, g.concat(g.prefix, path), handler, m...).With(g.meta)
 }
 
 // Group creates a new sub-group with prefix and optional sub-group-level middleware.
@@ -48,10 +52,27 @@ func (g *Group) Group(prefix string, middleware ...MiddlewareFunc) *Group {
 	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	return g.server.Group(g.concat(g.prefix, prefix), m...)
+	sg := g.server.Group(g.concat(g.prefix, prefix), m...)
+	if g.meta != nil {
+		sg.Meta(g.meta)
+	}
+	return sg
+}
+
+// Meta 设置路由组的元数据，之后注册的路由和子路由组继承这些元数据，路由自己的元数据优先
+func (g *Group) Meta(meta Meta) *Group {
+	if g.meta == nil {
+		g.meta = make(Meta, len(meta))
+	}
+	maps.Copy(g.meta, meta)
+	return g
 }
 
 func (g *Group) concat(a, b string) string {
+	return concatPath(a, b)
+}
+
+func concatPath(a, b string) string {
 	if a == "" {
 		return b
 	}
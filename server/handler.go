@@ -3,13 +3,15 @@ package server
 import (
 	stdContext "context"
 	"embed"
-	"encoding/json"
 	"errors"
 	"io/fs"
 	"net/http"
 	"path"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/coder/websocket"
 	"github.com/lazygo/pkg/waiter"
@@ -70,29 +72,127 @@ func AssetHandler(prefix string, assets embed.FS, root string) HandlerFunc {
 	return WrapHandler(http.StripPrefix(prefix, http.FileServer(http.FS(handler))))
 }
 
+const (
+	defaultWebSocketPingInterval = 30 * time.Second
+	defaultWebSocketPongTimeout  = 10 * time.Second
+	defaultWebSocketWriteTimeout = 10 * time.Second
+)
+
+// WebSocketConfig WebSocket连接的心跳和超时配置
+type WebSocketConfig struct {
+	// PingInterval 发送ping帧的间隔，默认30秒，小于0时不发送
+	PingInterval time.Duration
+	// PongTimeout 等待pong帧的超时时间，超时断开连接，默认10秒
+	PongTimeout time.Duration
+	// IdleTimeout 未收到客户端消息的最长时间，超时断开连接，为0时不限制
+	IdleTimeout time.Duration
+	// WriteTimeout 单条消息的写入超时时间，默认10秒
+	WriteTimeout time.Duration
+}
+
+// WebSocketWrapper 使用默认心跳配置包装WebSocket连接
 func WebSocketWrapper(ctx stdContext.Context, conn *websocket.Conn) SendReceiveCloser {
-	return &wsBridge{ctx: ctx, conn: conn}
+	return NewWebSocketBridge(ctx, conn, WebSocketConfig{})
+}
+
+// NewWebSocketBridge 包装WebSocket连接，定时发送ping帧检测连接是否存活
+// 消息编码由握手时协商的子协议决定，见 WebSocketSubprotocols
+func NewWebSocketBridge(ctx stdContext.Context, conn *websocket.Conn, conf WebSocketConfig) SendReceiveCloser {
+	if conf.PingInterval == 0 {
+		conf.PingInterval = defaultWebSocketPingInterval
+	}
+	if conf.PongTimeout <= 0 {
+		conf.PongTimeout = defaultWebSocketPongTimeout
+	}
+	if conf.WriteTimeout <= 0 {
+		conf.WriteTimeout = defaultWebSocketWriteTimeout
+	}
+	b := &wsBridge{
+		ctx:   ctx,
+		conn:  conn,
+		conf:  conf,
+		codec: EventCodecFor(conn.Subprotocol()),
+		done:  make(chan struct{}),
+	}
+	if conf.PingInterval > 0 || conf.IdleTimeout > 0 {
+		go b.keepalive()
+	}
+	return b
 }
 
 type wsBridge struct {
-	ctx  stdContext.Context
-	conn *websocket.Conn
+	ctx      stdContext.Context
+	conn     *websocket.Conn
+	conf     WebSocketConfig
+	codec    EventCodec
+	lastRead atomic.Int64
+	// readSince 开始等待客户端消息的时间，为0时读取暂停（如 Event 的处理数达到上限）
+	readSince atomic.Int64
+	done      chan struct{}
+	once      sync.Once
+}
+
+// keepalive 定时发送ping帧，未及时收到pong或空闲超时时断开连接
+// pong帧只在 Receive 的读取中处理，读取暂停时不发送ping也不计算空闲时间，
+// 期间收到客户端消息时同样认为连接存活
+func (b *wsBridge) keepalive() {
+	interval := b.conf.PingInterval
+	if interval <= 0 || (b.conf.IdleTimeout > 0 && b.conf.IdleTimeout < interval) {
+		interval = b.conf.IdleTimeout
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-b.done:
+			return
+		case <-ticker.C:
+		}
+		since := b.readSince.Load()
+		if since == 0 {
+			continue
+		}
+		if b.conf.IdleTimeout > 0 && time.Since(time.Unix(0, since)) > b.conf.IdleTimeout {
+			_ = b.conn.Close(websocket.StatusPolicyViolation, "idle timeout")
+			return
+		}
+		if b.conf.PingInterval > 0 {
+			start := time.Now().UnixNano()
+			ctx, cancel := stdContext.WithTimeout(b.ctx, b.conf.PongTimeout)
+			err := b.conn.Ping(ctx)
+			cancel()
+			if err != nil && b.ctx.Err() == nil && b.readSince.Load() != 0 && b.lastRead.Load() < start {
+				_ = b.conn.CloseNow()
+				return
+			}
+		}
+	}
 }
 
 func (b *wsBridge) Receive(ctx stdContext.Context) (*EventData, error) {
 	for {
-		_, msg, err := b.conn.Read(b.ctx)
+		b.readSince.Store(time.Now().UnixNano())
+		typ, msg, err := b.conn.Read(ctx)
+		b.readSince.Store(0)
 		if err != nil {
 			return nil, err
 		}
+		b.lastRead.Store(time.Now().UnixNano())
 
-		if len(msg) == 4 && strings.ToLower(string(msg)) == "ping" {
-			b.conn.Write(b.ctx, websocket.MessageText, []byte("pong"))
-			continue
+		codec := b.codec
+		if typ == websocket.MessageText {
+			if len(msg) == 4 && strings.ToLower(string(msg)) == "ping" {
+				_ = b.write(websocket.MessageText, []byte("pong"))
+				continue
+			}
+			// 文本帧始终按json解析
+			codec = jsonCodec{}
 		}
 
 		var req EventData
-		err = json.Unmarshal(msg, &req)
+		err = codec.Unmarshal(msg, &req)
 		if err != nil {
 			continue
 		}
@@ -101,18 +201,23 @@ func (b *wsBridge) Receive(ctx stdContext.Context) (*EventData, error) {
 }
 
 func (b *wsBridge) Send(data *EventData) error {
-	msg, err := json.Marshal(data)
-	if err != nil {
-		return err
-	}
-	err = b.conn.Write(b.ctx, websocket.MessageText, msg)
+	msg, err := b.codec.Marshal(data)
 	if err != nil {
 		return err
 	}
-	return nil
+	return b.write(b.codec.MessageType(), msg)
+}
+
+func (b *wsBridge) write(typ websocket.MessageType, msg []byte) error {
+	ctx, cancel := stdContext.WithTimeout(b.ctx, b.conf.WriteTimeout)
+	defer cancel()
+	return b.conn.Write(ctx, typ, msg)
 }
 
 func (b *wsBridge) Close() error {
+	b.once.Do(func() {
+		close(b.done)
+	})
 	return b.conn.Close(websocket.StatusNormalClosure, "normal closure")
 }
 
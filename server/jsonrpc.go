@@ -0,0 +1,370 @@
+package server
+
+// JSON-RPC 2.0，将控制器方法以 service.method 的名称对外提供，与 Controller 共用请求参数的绑定和校验
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/lazygo/lazygo/utils"
+)
+
+const (
+	JSONRPCVersion = "2.0"
+
+	// 标准错误码
+	JSONRPCParseError     = -32700
+	JSONRPCInvalidRequest = -32600
+	JSONRPCMethodNotFound = -32601
+	JSONRPCInvalidParams  = -32602
+	JSONRPCInternalError  = -32603
+	// JSONRPCServerError 没有业务错误码的 HTTPError
+	JSONRPCServerError = -32000
+)
+
+const (
+	defaultJSONRPCMaxItems    = 20
+	defaultJSONRPCMaxBodySize = 1 << 20 // 1 MB
+)
+
+var jsonNull = json.RawMessage("null")
+
+type (
+	// JSONRPCConfig JSON-RPC配置
+	JSONRPCConfig struct {
+		// Services 服务名对应的控制器，如 {"user": controller.UserController{}}，
+		// 方法 user.get_info 和 user.GetInfo 都调用 UserController.GetInfo
+		Services map[string]any
+		// MaxItems 批量调用最多包含的请求数，默认20
+		MaxItems int
+		// Concurrency 批量调用的并发数，小于等于1时顺序执行
+		Concurrency int
+		// MaxBodySize 请求体最大字节数，默认1MB
+		MaxBodySize int64
+	}
+
+	// JSONRPCRequest JSON-RPC请求，没有id的请求为通知，不返回响应
+	JSONRPCRequest struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Method  string          `json:"method"`
+		Params  json.RawMessage `json:"params,omitempty"`
+		ID      json.RawMessage `json:"id,omitempty"`
+	}
+
+	// JSONRPCResponse JSON-RPC响应
+	JSONRPCResponse struct {
+		JSONRPC string          `json:"jsonrpc"`
+		Result  json.RawMessage `json:"result,omitempty"`
+		Error   *JSONRPCError   `json:"error,omitempty"`
+		ID      json.RawMessage `json:"id"`
+	}
+
+	// JSONRPCError JSON-RPC错误，控制器方法可以直接返回该错误以指定错误码
+	JSONRPCError struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Data    any    `json:"data,omitempty"`
+	}
+)
+
+func (e *JSONRPCError) Error() string {
+	return fmt.Sprintf("jsonrpc error %d: %s", e.Code, e.Message)
+}
+
+type rpcService struct {
+	rt   reflect.Type
+	name string
+}
+
+type jsonRPC struct {
+	conf     JSONRPCConfig
+	services map[string]rpcService
+}
+
+// JSONRPC JSON-RPC 2.0 处理器，支持批量调用和通知，只支持按名称传递的参数（params为对象）
+// params 作为json请求体绑定到方法的请求参数，header、cookie、ctx 等绑定仍从原请求获取
+// 错误码：参数绑定和校验失败为 JSONRPCInvalidParams；方法返回的 HTTPError 有业务错误码时使用 Errno，
+// 否则按状态码映射，400、422 为 JSONRPCInvalidParams，500 为 JSONRPCInternalError，其他为 JSONRPCServerError
+func JSONRPC(conf JSONRPCConfig) HandlerFunc {
+	if conf.MaxItems <= 0 {
+		conf.MaxItems = defaultJSONRPCMaxItems
+	}
+	if conf.MaxBodySize <= 0 {
+		conf.MaxBodySize = defaultJSONRPCMaxBodySize
+	}
+	h := &jsonRPC{conf: conf, services: make(map[string]rpcService, len(conf.Services))}
+	for name, ctrl := range conf.Services {
+		rt, serviceName, err := routes.Make(ctrl)
+		if err != nil {
+			panic(err)
+		}
+		h.services[name] = rpcService{rt: rt, name: serviceName}
+	}
+	return h.serve
+}
+
+func (h *jsonRPC) serve(c Context) error {
+	body, err := io.ReadAll(http.MaxBytesReader(c.ResponseWriter(), c.Request().Body, h.conf.MaxBodySize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			return ErrStatusRequestEntityTooLarge.SetInternal(err)
+		}
+		return ErrBadRequest.SetInternal(err)
+	}
+	body = bytes.TrimSpace(body)
+
+	if len(body) == 0 || body[0] != '[' {
+		resp := h.call(c, body)
+		if resp == nil {
+			return c.NoContent(http.StatusNoContent)
+		}
+		return c.JSON(http.StatusOK, resp)
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(body, &items); err != nil {
+		return c.JSON(http.StatusOK, rpcErrorResponse(nil, JSONRPCParseError, "Parse error"))
+	}
+	if len(items) == 0 {
+		return c.JSON(http.StatusOK, rpcErrorResponse(nil, JSONRPCInvalidRequest, "Invalid Request"))
+	}
+	if len(items) > h.conf.MaxItems {
+		return c.JSON(http.StatusOK, rpcErrorResponse(nil, JSONRPCInvalidRequest, fmt.Sprintf("%s: %d > %d", ErrBatchTooLarge, len(items), h.conf.MaxItems)))
+	}
+
+	results := make([]*JSONRPCResponse, len(items))
+	if h.conf.Concurrency <= 1 {
+		for i, item := range items {
+			results[i] = h.call(c, item)
+		}
+	} else {
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, h.conf.Concurrency)
+		for i, item := range items {
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				results[i] = h.call(c, item)
+			}()
+		}
+		wg.Wait()
+	}
+
+	// 通知不返回响应，全部为通知时返回204
+	list := make([]*JSONRPCResponse, 0, len(results))
+	for _, resp := range results {
+		if resp != nil {
+			list = append(list, resp)
+		}
+	}
+	if len(list) == 0 {
+		return c.NoContent(http.StatusNoContent)
+	}
+	return c.JSON(http.StatusOK, list)
+}
+
+// call 处理单个请求，通知返回nil
+func (h *jsonRPC) call(c Context, raw json.RawMessage) *JSONRPCResponse {
+	if !json.Valid(raw) {
+		return rpcErrorResponse(nil, JSONRPCParseError, "Parse error")
+	}
+	var req JSONRPCRequest
+	if err := json.Unmarshal(raw, &req); err != nil || req.JSONRPC != JSONRPCVersion || req.Method == "" || !validRPCID(req.ID) {
+		return rpcErrorResponse(nil, JSONRPCInvalidRequest, "Invalid Request")
+	}
+
+	result, rerr := h.invoke(c, &req)
+	if len(req.ID) == 0 {
+		return nil
+	}
+	resp := &JSONRPCResponse{JSONRPC: JSONRPCVersion, ID: req.ID}
+	if rerr != nil {
+		resp.Error = rerr
+		return resp
+	}
+	resp.Result = result
+	if len(resp.Result) == 0 {
+		resp.Result = jsonNull
+	}
+	return resp
+}
+
+// invoke 调用控制器方法，为每次调用创建独立的Context，params 作为json请求体
+func (h *jsonRPC) invoke(c Context, req *JSONRPCRequest) (result json.RawMessage, rerr *JSONRPCError) {
+	index := strings.LastIndexByte(req.Method, '.')
+	if index <= 0 {
+		return nil, &JSONRPCError{Code: JSONRPCMethodNotFound, Message: "Method not found"}
+	}
+	service, ok := h.services[req.Method[:index]]
+	if !ok {
+		return nil, &JSONRPCError{Code: JSONRPCMethodNotFound, Message: "Method not found"}
+	}
+	method, ok := routes[service.name][utils.ToSnakeString(req.Method[index+1:])]
+	if !ok {
+		return nil, &JSONRPCError{Code: JSONRPCMethodNotFound, Message: "Method not found"}
+	}
+
+	params := bytes.TrimSpace(req.Params)
+	if bytes.Equal(params, jsonNull) {
+		params = nil
+	}
+	if len(params) > 0 && params[0] != '{' {
+		return nil, &JSONRPCError{Code: JSONRPCInvalidParams, Message: "Invalid params", Data: "params must be an object"}
+	}
+
+	s := c.c().s()
+	parent := c.Request()
+	r, err := http.NewRequestWithContext(c, http.MethodPost, parent.URL.String(), bytes.NewReader(params))
+	if err != nil {
+		return nil, s.rpcError(c, err, JSONRPCInternalError)
+	}
+	r.RequestURI = parent.RequestURI
+	r.RemoteAddr = parent.RemoteAddr
+	r.TLS = parent.TLS
+	r.Host = parent.Host
+	r.Header = parent.Header.Clone()
+	r.Header.Del(HeaderContentLength)
+	r.Header.Del(HeaderContentEncoding)
+	r.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	w := &batchResponseWriter{header: http.Header{}}
+	ctx := s.newContext(r, w)
+
+	defer func() {
+		if p := recover(); p != nil {
+			result, rerr = nil, s.rpcError(c, fmt.Errorf("panic: %v", p), JSONRPCInternalError)
+		}
+	}()
+
+	pReq, preq := newRequest(method)
+	if preq, ok := preq.(interface{ Clear() }); ok {
+		defer preq.Clear()
+	}
+	if err := bindRequest(ctx, pReq, preq); err != nil {
+		return nil, s.rpcError(c, err, JSONRPCInvalidParams)
+	}
+
+	out := callMethod(ctx, service.rt, method, pReq)
+	if ierr := out[len(out)-1].Interface(); ierr != nil {
+		if err := ierr.(error); err != nil {
+			return nil, s.rpcError(c, err, 0)
+		}
+	}
+	if len(out) == 2 {
+		result, err = json.Marshal(out[0].Interface())
+		if err != nil {
+			return nil, s.rpcError(c, err, JSONRPCInternalError)
+		}
+		return result, nil
+	}
+	// 没有返回值的方法使用写入的响应作为结果
+	body := bytes.TrimSpace(w.buf.Bytes())
+	if len(body) == 0 {
+		return nil, nil
+	}
+	if json.Valid(body) {
+		return body, nil
+	}
+	result, _ = json.Marshal(w.buf.String())
+	return result, nil
+}
+
+// rpcError 将错误转换为JSON-RPC错误，code 为0时按 HTTPError 的业务错误码或状态码确定
+func (s *Server) rpcError(c Context, err error, code int) *JSONRPCError {
+	var je *JSONRPCError
+	if errors.As(err, &je) {
+		return je
+	}
+
+	var he *HTTPError
+	if !errors.As(err, &he) {
+		if code == 0 {
+			code = JSONRPCInternalError
+		}
+		e := &JSONRPCError{Code: code, Message: "Internal error"}
+		if code == JSONRPCInvalidParams {
+			e.Message = "Invalid params"
+		}
+		if s.Debug {
+			e.Data = Map{"error": err.Error()}
+		}
+		return e
+	}
+
+	if herr, ok := he.Internal.(*HTTPError); ok {
+		he = herr
+	}
+	if code == 0 {
+		switch {
+		case he.Errno != he.Code && he.Errno != 0:
+			code = he.Errno
+		case he.Code == http.StatusBadRequest || he.Code == http.StatusUnprocessableEntity:
+			code = JSONRPCInvalidParams
+		case he.Code == http.StatusInternalServerError:
+			code = JSONRPCInternalError
+		default:
+			code = JSONRPCServerError
+		}
+	}
+	e := &JSONRPCError{Code: code}
+	data := Map{"status": he.Code}
+	if he.Errno != he.Code {
+		data["errno"] = he.Errno
+	}
+	switch m := s.message(he, c).(type) {
+	case string:
+		e.Message = m
+	case nil:
+	default:
+		data["message"] = m
+	}
+	if e.Message == "" || e.Message == http.StatusText(he.Code) {
+		switch code {
+		case JSONRPCInvalidParams:
+			e.Message = "Invalid params"
+		case JSONRPCInternalError:
+			e.Message = "Internal error"
+		default:
+			e.Message = http.StatusText(he.Code)
+		}
+	}
+	if s.Debug {
+		data["error"] = err.Error()
+	}
+	e.Data = data
+	return e
+}
+
+func rpcErrorResponse(id json.RawMessage, code int, message string) *JSONRPCResponse {
+	if len(id) == 0 {
+		id = jsonNull
+	}
+	return &JSONRPCResponse{
+		JSONRPC: JSONRPCVersion,
+		Error:   &JSONRPCError{Code: code, Message: message},
+		ID:      id,
+	}
+}
+
+// validRPCID id 只能为字符串、数字或null
+func validRPCID(id json.RawMessage) bool {
+	if len(id) == 0 {
+		return true
+	}
+	switch c := id[0]; {
+	case c == '"', c == '-', c >= '0' && c <= '9':
+		return true
+	}
+	return bytes.Equal(id, jsonNull)
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+var rpcNotified atomic.Int32
+
+type rpcUserController struct {
+	Ctx Context
+}
+
+type rpcUserRequest struct {
+	UID   int    `json:"uid"`
+	Token string `json:"X-Token" bind:"header"`
+}
+
+func (r *rpcUserRequest) Verify() error {
+	if r.UID <= 0 {
+		return NewHTTPError(http.StatusBadRequest, 10001, "uid required")
+	}
+	return nil
+}
+
+func (ctl *rpcUserController) GetInfo(req *rpcUserRequest) (any, error) {
+	if req.UID == 404 {
+		return nil, ErrNotFound
+	}
+	if req.UID == 500 {
+		return nil, errors.New("db down")
+	}
+	return Map{"uid": req.UID, "token": req.Token}, nil
+}
+
+func (ctl *rpcUserController) Touch() error {
+	rpcNotified.Add(1)
+	return nil
+}
+
+func rpcCall(t *testing.T, s *Server, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/rpc", strings.NewReader(body))
+	req.Header.Set(HeaderContentType, MIMEApplicationJSON)
+	req.Header.Set("X-Token", "t1")
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec.Code, strings.TrimSpace(rec.Body.String())
+}
+
+func TestJSONRPC(t *testing.T) {
+	s := New()
+	s.Post("/rpc", JSONRPC(JSONRPCConfig{Services: map[string]any{"user": rpcUserController{}}}))
+
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "call",
+			body: `{"jsonrpc":"2.0","method":"user.get_info","params":{"uid":1},"id":1}`,
+			want: `{"jsonrpc":"2.0","result":{"token":"t1","uid":1},"id":1}`,
+		},
+		{
+			name: "camel case method",
+			body: `{"jsonrpc":"2.0","method":"user.GetInfo","params":{"uid":2},"id":"a"}`,
+			want: `{"jsonrpc":"2.0","result":{"token":"t1","uid":2},"id":"a"}`,
+		},
+		{
+			name: "verify failure",
+			body: `{"jsonrpc":"2.0","method":"user.get_info","params":{},"id":2}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"uid required","data":{"errno":10001,"status":400}},"id":2}`,
+		},
+		{
+			name: "bind failure",
+			body: `{"jsonrpc":"2.0","method":"user.get_info","params":{"uid":"x"},"id":3}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":{"status":400}},"id":3}`,
+		},
+		{
+			name: "http error",
+			body: `{"jsonrpc":"2.0","method":"user.get_info","params":{"uid":404},"id":4}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32000,"message":"Not Found","data":{"status":404}},"id":4}`,
+		},
+		{
+			name: "internal error",
+			body: `{"jsonrpc":"2.0","method":"user.get_info","params":{"uid":500},"id":5}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error"},"id":5}`,
+		},
+		{
+			name: "method not found",
+			body: `{"jsonrpc":"2.0","method":"user.missing","id":6}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":6}`,
+		},
+		{
+			name: "positional params",
+			body: `{"jsonrpc":"2.0","method":"user.get_info","params":[1],"id":7}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params","data":"params must be an object"},"id":7}`,
+		},
+		{
+			name: "invalid request",
+			body: `{"jsonrpc":"1.0","method":"user.get_info","id":8}`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`,
+		},
+		{
+			name: "parse error",
+			body: `{"jsonrpc":"2.0","method"`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32700,"message":"Parse error"},"id":null}`,
+		},
+		{
+			name: "empty batch",
+			body: `[]`,
+			want: `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}`,
+		},
+		{
+			name: "batch with notification",
+			body: `[{"jsonrpc":"2.0","method":"user.touch"},{"jsonrpc":"2.0","method":"user.get_info","params":{"uid":3},"id":9},1]`,
+			want: `[{"jsonrpc":"2.0","result":{"token":"t1","uid":3},"id":9},{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request"},"id":null}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, body := rpcCall(t, s, tt.body)
+			if code != http.StatusOK {
+				t.Fatalf("status = %d", code)
+			}
+			if !jsonEqual(body, tt.want) {
+				t.Fatalf("got %s\nwant %s", body, tt.want)
+			}
+		})
+	}
+
+	rpcNotified.Store(0)
+	code, body := rpcCall(t, s, `[{"jsonrpc":"2.0","method":"user.touch"},{"jsonrpc":"2.0","method":"user.touch","params":null}]`)
+	if code != http.StatusNoContent || body != "" || rpcNotified.Load() != 2 {
+		t.Fatalf("notifications: status = %d, body = %q, notified = %d", code, body, rpcNotified.Load())
+	}
+}
+
+func jsonEqual(a, b string) bool {
+	var va, vb any
+	if json.Unmarshal([]byte(a), &va) != nil || json.Unmarshal([]byte(b), &vb) != nil {
+		return false
+	}
+	ja, _ := json.Marshal(va)
+	jb, _ := json.Marshal(vb)
+	return string(ja) == string(jb)
+}
@@ -0,0 +1,146 @@
+package server
+
+// 长轮询，不能使用WebSocket或SSE的客户端通过普通HTTP请求等待通知
+//
+//	g.Get("orders/:id/wait", func(c server.Context) error {
+//		id, _ := c.Param("id")
+//		data, err := c.WaitFor("order:"+id, 30*time.Second)
+//		if errors.Is(err, server.ErrWaitTimeout) {
+//			return c.NoContent(http.StatusNoContent)
+//		}
+//		if err != nil {
+//			return err
+//		}
+//		return c.JSON(http.StatusOK, data)
+//	})
+//
+//	// 订单状态变化时，在任意节点通知
+//	s.Notify(ctx, "order:"+id, order)
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lazygo/pkg/waiter"
+)
+
+const (
+	clusterNotifyChannel = "lazygo:poll:notify"
+	clusterMsgNotify     = "notify"
+
+	defaultPollTimeout = 30 * time.Second
+)
+
+var ErrWaitTimeout = errors.New("wait timeout")
+
+// poller 当前节点上等待中的长轮询，同一个key可以有多个等待者
+type poller struct {
+	seq    atomic.Uint64
+	mu     sync.Mutex
+	keys   map[string]map[string]struct{}
+	waiter *waiter.Waiter[json.RawMessage]
+}
+
+func newPoller() *poller {
+	return &poller{
+		keys:   make(map[string]map[string]struct{}),
+		waiter: waiter.NewWaiter[json.RawMessage](),
+	}
+}
+
+// wait 等待key的通知，超时返回 ErrWaitTimeout，ctx结束时返回ctx的错误
+func (p *poller) wait(ctx stdContext.Context, key string, timeout time.Duration) (json.RawMessage, error) {
+	if timeout <= 0 {
+		timeout = defaultPollTimeout
+	}
+	// 每个等待者使用独立的waiter key
+	id := key + "#" + strconv.FormatUint(p.seq.Add(1), 10)
+	waitCtx, cancel := stdContext.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	wait, stop := p.waiter.Get(waitCtx, id)
+	p.mu.Lock()
+	if p.keys[key] == nil {
+		p.keys[key] = make(map[string]struct{})
+	}
+	p.keys[key][id] = struct{}{}
+	p.mu.Unlock()
+	defer func() {
+		stop()
+		p.mu.Lock()
+		delete(p.keys[key], id)
+		if len(p.keys[key]) == 0 {
+			delete(p.keys, key)
+		}
+		p.mu.Unlock()
+	}()
+
+	data, err := wait()
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, ErrWaitTimeout
+	}
+	return data, nil
+}
+
+// notify 通知当前节点上等待key的所有请求，返回通知到的数量
+func (p *poller) notify(key string, data json.RawMessage) int {
+	p.mu.Lock()
+	ids := make([]string, 0, len(p.keys[key]))
+	for id := range p.keys[key] {
+		ids = append(ids, id)
+	}
+	p.mu.Unlock()
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), time.Second)
+	defer cancel()
+	n := 0
+	for _, id := range ids {
+		if ok, _ := p.waiter.Put(ctx, id, data); ok {
+			n++
+		}
+	}
+	return n
+}
+
+func (c *context) WaitFor(key string, timeout time.Duration) (json.RawMessage, error) {
+	return c.s().poller.wait(c.Request().Context(), key, timeout)
+}
+
+// Notify 通知所有等待key的长轮询请求，data 序列化为json后作为 Context.WaitFor 的结果
+// 启用集群时通过 Backplane 转发到其他节点（见 EnableCluster、NewMemoryBackplane、NewRedisBackplane），
+// 没有请求在等待时通知被丢弃，客户端应在超时后重新获取最新状态
+func (s *Server) Notify(ctx stdContext.Context, key string, data any) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	s.poller.notify(key, b)
+	if c := s.eventManager.cluster; c != nil {
+		return c.publish(ctx, clusterNotifyChannel, &clusterMessage{
+			Type:    clusterMsgNotify,
+			Key:     key,
+			Payload: b,
+		})
+	}
+	return nil
+}
+
+// receiveNotify 处理其他节点发来的长轮询通知
+func (c *cluster) receiveNotify(b []byte) {
+	var msg clusterMessage
+	if err := json.Unmarshal(b, &msg); err != nil || msg.Type != clusterMsgNotify {
+		return
+	}
+	if msg.Node == c.node {
+		return
+	}
+	c.em.server.poller.notify(msg.Key, msg.Payload)
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	stdContext "context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func waitPollers(t *testing.T, s *Server, key string, n int) {
+	t.Helper()
+	for i := 0; ; i++ {
+		s.poller.mu.Lock()
+		got := len(s.poller.keys[key])
+		s.poller.mu.Unlock()
+		if got == n {
+			return
+		}
+		if i > 100 {
+			t.Fatalf("%d pollers waiting for %s, want %d", got, key, n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestLongPoll(t *testing.T) {
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	defer cancel()
+
+	backplane := NewMemoryBackplane()
+	s1, s2 := New(), New()
+	if err := s1.EnableCluster(ctx, "node1", backplane); err != nil {
+		t.Fatal(err)
+	}
+	if err := s2.EnableCluster(ctx, "node2", backplane); err != nil {
+		t.Fatal(err)
+	}
+	s2.Get("/wait/:key", func(c Context) error {
+		key, _ := c.Param("key")
+		data, err := c.WaitFor(key, 100*time.Millisecond)
+		if errors.Is(err, ErrWaitTimeout) {
+			return c.NoContent(http.StatusNoContent)
+		}
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, data)
+	})
+
+	// 同一个key的多个等待者都收到其他节点的通知
+	var wg sync.WaitGroup
+	bodies := make([]string, 2)
+	for i := range bodies {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/wait/order:1", nil)
+			s2.ServeHTTP(rec, req)
+			bodies[i] = rec.Body.String()
+		}()
+	}
+	waitPollers(t, s2, "order:1", 2)
+	if err := s1.Notify(ctx, "order:1", Map{"status": "paid"}); err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+	for _, body := range bodies {
+		if !jsonEqual(body, `{"status":"paid"}`) {
+			t.Errorf("body = %q", body)
+		}
+	}
+	waitPollers(t, s2, "order:1", 0)
+
+	rec := httptest.NewRecorder()
+	s2.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/wait/order:2", nil))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("timeout status = %d", rec.Code)
+	}
+
+	// 客户端断开时返回请求context的错误
+	reqCtx, reqCancel := stdContext.WithCancel(ctx)
+	done := make(chan error, 1)
+	go func() {
+		c := s1.NewContext(httptest.NewRequest(http.MethodGet, "/", nil).WithContext(reqCtx), httptest.NewRecorder())
+		_, err := c.WaitFor("order:3", time.Second)
+		done <- err
+	}()
+	waitPollers(t, s1, "order:3", 1)
+	reqCancel()
+	if err := <-done; !errors.Is(err, stdContext.Canceled) {
+		t.Fatalf("disconnect err = %v", err)
+	}
+}
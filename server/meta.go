@@ -0,0 +1,92 @@
+package server
+
+// 路由元数据，在注册路由时声明，中间件通过 Context.RouteMeta 读取，如
+//
+//	g := s.Group("/api/admin", middleware.Auth).Meta(server.Meta{"team": "ops", "audit": true})
+//	g.Get("users", listUsers).With(server.Meta{"permissions": []string{"user:read"}, "ratelimit": "low"})
+//
+//	func Auth(next server.HandlerFunc) server.HandlerFunc {
+//		return func(c server.Context) error {
+//			for _, p := range c.RouteMeta().Strings("permissions") {
+//				...
+//			}
+//			return next(c)
+//		}
+//	}
+
+import (
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Meta 路由元数据
+type Meta map[string]any
+
+// String 获取字符串，不存在或类型不匹配时返回空字符串
+func (m Meta) String(key string) string {
+	v, _ := m[key].(string)
+	return v
+}
+
+// Bool 获取布尔值，不存在或类型不匹配时返回false
+func (m Meta) Bool(key string) bool {
+	v, _ := m[key].(bool)
+	return v
+}
+
+// Strings 获取字符串列表，值为字符串时返回只有一个元素的列表
+func (m Meta) Strings(key string) []string {
+	switch v := m[key].(type) {
+	case []string:
+		return v
+	case string:
+		return []string{v}
+	}
+	return nil
+}
+
+// RouteInfo 已注册的路由
+type RouteInfo struct {
+	Method string
+	Path   string
+	Meta   Meta
+	// resolve 按请求确定实际的路由，如不带版本前缀的版本路由按协商的版本返回对应版本的路由
+	resolve func(c Context) *RouteInfo
+}
+
+// Routes 注册路由时返回的路由列表，用于设置元数据
+type Routes []*RouteInfo
+
+// With 合并元数据到所有路由，同名的key覆盖已有的值
+func (rs Routes) With(meta Meta) Routes {
+	if len(meta) == 0 {
+		return rs
+	}
+	for _, r := range rs {
+		if r.Meta == nil {
+			r.Meta = make(Meta, len(meta))
+		}
+		maps.Copy(r.Meta, meta)
+	}
+	return rs
+}
+
+// Routes 获取所有路由及其元数据，按路径和方法排序
+func (s *Server) Routes() []*RouteInfo {
+	list := slices.Collect(maps.Values(s.routes))
+	slices.SortFunc(list, func(a, b *RouteInfo) int {
+		if c := strings.Compare(a.Path, b.Path); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return list
+}
+
+func (c *context) RouteMeta() Meta {
+	if c.route == nil {
+		return nil
+	}
+	return c.route.Meta
+}
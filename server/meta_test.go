@@ -0,0 +1,58 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRouteMeta(t *testing.T) {
+	s := New()
+	// 全局中间件在路由处理之前读取元数据
+	s.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			meta := c.RouteMeta()
+			c.ResponseWriter().Header().Set("X-Team", meta.String("team"))
+			c.ResponseWriter().Header().Set("X-Permissions", strings.Join(meta.Strings("permissions"), ","))
+			if meta.Bool("audit") {
+				c.ResponseWriter().Header().Set("X-Audit", "1")
+			}
+			return next(c)
+		}
+	})
+	ok := func(c Context) error { return c.NoContent(http.StatusOK) }
+
+	admin := s.Group("/admin").Meta(Meta{"team": "ops", "audit": true})
+	admin.Get("users", ok).With(Meta{"permissions": []string{"user:read"}})
+	admin.Group("orders").Meta(Meta{"team": "trade"}).Post(":id", ok).With(Meta{"permissions": "order:write", "audit": false})
+	s.Get("/ping", ok)
+
+	tests := []struct {
+		method, path             string
+		team, permissions, audit string
+	}{
+		{http.MethodGet, "/admin/users", "ops", "user:read", "1"},
+		{http.MethodPost, "/admin/orders/1", "trade", "order:write", ""},
+		{http.MethodGet, "/ping", "", "", ""},
+		{http.MethodGet, "/missing", "", "", ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		h := rec.Header()
+		if h.Get("X-Team") != tt.team || h.Get("X-Permissions") != tt.permissions || h.Get("X-Audit") != tt.audit {
+			t.Errorf("%s %s: team = %q, permissions = %q, audit = %q", tt.method, tt.path, h.Get("X-Team"), h.Get("X-Permissions"), h.Get("X-Audit"))
+		}
+	}
+
+	var list []string
+	for _, r := range s.Routes() {
+		list = append(list, r.Method+" "+r.Path+" "+r.Meta.String("team"))
+	}
+	want := []string{"POST /admin/orders/:id trade", "GET /admin/users ops", "GET /ping "}
+	if !slices.Equal(list, want) {
+		t.Fatalf("routes = %q, want %q", list, want)
+	}
+}
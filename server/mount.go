@@ -0,0 +1,64 @@
+package server
+
+// 挂载子服务，将前缀下的所有请求交给 http.Handler 处理，如单独构建的 *Server 或第三方的 http.Handler
+//
+//	plugin := server.New()
+//	plugin.Get("/status", status)
+//	s.Group("/api", middleware.AuthUser).Mount("/plugin", plugin)
+//	// GET /api/plugin/status 经过 AuthUser 后由 plugin 处理，plugin 中的路径为 /status
+//
+//	s.Mount("/legacy", legacyMux)
+
+import (
+	stdContext "context"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+type originalPathKey struct{}
+
+// Mount 将 prefix 下所有方法的请求去掉前缀后交给 h 处理，路由组的中间件先于 h 执行
+// 404和405由 h 处理，挂载前的原始路径通过 Context.OriginalPath 或 OriginalPath 获取
+func (cm *common) Mount(prefix string, h http.Handler, middleware ...MiddlewareFunc) Routes {
+	prefix = strings.TrimRight(prefix, "/")
+	handler := func(c Context) error {
+		rest, _ := c.Param("*")
+		r := c.Request()
+		// rest 为转义后的路径
+		p, err := url.PathUnescape("/" + rest)
+		if err != nil {
+			return ErrBadRequest.SetInternal(err)
+		}
+		ctx := r.Context()
+		if _, ok := ctx.Value(originalPathKey{}).(string); !ok {
+			// 多层挂载时保留最外层的原始路径
+			ctx = stdContext.WithValue(ctx, originalPathKey{}, r.URL.Path)
+		}
+		r = r.WithContext(ctx)
+		u := *r.URL
+		u.Path = p
+		u.RawPath = "/" + rest
+		r.URL = &u
+		h.ServeHTTP(c.ResponseWriter(), r)
+		return nil
+	}
+
+	var routes Routes
+	if prefix != "" {
+		routes = append(routes, cm.Any(prefix, handler, middleware...)...)
+	}
+	return append(routes, cm.Any(prefix+"/*", handler, middleware...)...)
+}
+
+// OriginalPath 获取 Mount 去掉前缀之前的请求路径，不是挂载的请求时返回当前路径
+func OriginalPath(r *http.Request) string {
+	if p, ok := r.Context().Value(originalPathKey{}).(string); ok {
+		return p
+	}
+	return r.URL.Path
+}
+
+func (c *context) OriginalPath() string {
+	return OriginalPath(c.Request())
+}
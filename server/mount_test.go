@@ -0,0 +1,65 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMount(t *testing.T) {
+	child := New()
+	child.Get("/status", func(c Context) error {
+		return c.Blob(http.StatusOK, MIMETextPlain, []byte(c.Request().URL.Path+" "+c.OriginalPath()))
+	})
+	inner := New()
+	inner.Get("/deep", func(c Context) error {
+		return c.Blob(http.StatusOK, MIMETextPlain, []byte(c.Request().URL.Path+" "+c.OriginalPath()))
+	})
+	child.Mount("/inner", inner)
+
+	legacy := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path + " " + r.URL.EscapedPath() + " " + OriginalPath(r)))
+	})
+
+	s := New()
+	g := s.Group("/api", func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			c.ResponseWriter().Header().Set("X-Group", "api")
+			return next(c)
+		}
+	})
+	g.Mount("/plugin/", child).With(Meta{"team": "plugin"})
+	s.Mount("/legacy", legacy)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+		want   string
+	}{
+		{http.MethodGet, "/api/plugin/status", http.StatusOK, "/status /api/plugin/status"},
+		{http.MethodGet, "/api/plugin/inner/deep", http.StatusOK, "/deep /api/plugin/inner/deep"},
+		// 404和405由子服务处理
+		{http.MethodGet, "/api/plugin/missing", http.StatusNotFound, `"message":"Not Found"`},
+		{http.MethodPost, "/api/plugin/status", http.StatusMethodNotAllowed, `"message":"Method Not Allowed"`},
+		{http.MethodGet, "/legacy", http.StatusOK, "/ / /legacy"},
+		{http.MethodGet, "/legacy/a%2Fb/c", http.StatusOK, "/a/b/c /a%2Fb/c /legacy/a/b/c"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, nil))
+		if rec.Code != tt.code || !strings.Contains(rec.Body.String(), tt.want) {
+			t.Errorf("%s %s: status = %d, body = %q", tt.method, tt.path, rec.Code, rec.Body.String())
+		}
+		if group := rec.Header().Get("X-Group"); (group == "api") != strings.HasPrefix(tt.path, "/api") {
+			t.Errorf("%s %s: group middleware = %q", tt.method, tt.path, group)
+		}
+	}
+
+	for _, r := range s.Routes() {
+		if strings.HasPrefix(r.Path, "/api/plugin") && r.Meta.String("team") != "plugin" {
+			t.Errorf("%s %s: meta = %v", r.Method, r.Path, r.Meta)
+		}
+	}
+}
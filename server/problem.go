@@ -0,0 +1,206 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ProblemType 问题类型，见 RFC 7807
+type ProblemType struct {
+	// Type 问题类型的URI，为空时为 about:blank
+	Type string
+	// Title 问题类型的简短描述，为空时使用状态码的描述
+	Title string
+	// Status 状态码，为0时使用错误本身的状态码，无法确定时为500
+	Status int
+}
+
+// Problem RFC 7807 问题详情
+type Problem struct {
+	Type     string `json:"type"`
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+	// Extensions 扩展字段，与标准字段同级输出，如 errno、errors
+	Extensions map[string]any `json:"-"`
+}
+
+// MarshalJSON 将扩展字段与标准字段合并输出，扩展字段不能覆盖标准字段
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	m := make(Map, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+	m["type"] = p.Type
+	m["title"] = p.Title
+	m["status"] = p.Status
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+// FieldError 单个字段的校验错误
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationError 参数校验错误，使用 ProblemHTTPErrorHandler 或由请求参数的 Verify 返回时输出为422，
+// 字段错误输出到扩展字段 errors
+type ValidationError struct {
+	Fields []FieldError
+}
+
+// NewValidationError 创建参数校验错误
+func NewValidationError(fields ...FieldError) *ValidationError {
+	return &ValidationError{Fields: fields}
+}
+
+// Add 添加字段错误
+func (ve *ValidationError) Add(field, message string) *ValidationError {
+	ve.Fields = append(ve.Fields, FieldError{Field: field, Message: message})
+	return ve
+}
+
+func (ve *ValidationError) Error() string {
+	list := make([]string, 0, len(ve.Fields))
+	for _, f := range ve.Fields {
+		list = append(list, f.Field+": "+f.Message)
+	}
+	return "validation failed: " + strings.Join(list, "; ")
+}
+
+// Unwrap 使 errors.Is(err, ErrUnprocessableEntity) 成立
+func (ve *ValidationError) Unwrap() error {
+	return ErrUnprocessableEntity
+}
+
+type problemMapping struct {
+	err error
+	pt  ProblemType
+}
+
+type problemRegistry struct {
+	mu       sync.RWMutex
+	mappings []problemMapping
+	status   map[int]ProblemType
+}
+
+// RegisterProblem 注册错误对应的问题类型，处理函数返回的错误满足 errors.Is(err, target) 时使用
+// 先注册的优先匹配
+func (s *Server) RegisterProblem(target error, pt ProblemType) {
+	s.problems.mu.Lock()
+	defer s.problems.mu.Unlock()
+	s.problems.mappings = append(s.problems.mappings, problemMapping{err: target, pt: pt})
+}
+
+// RegisterProblemStatus 注册状态码对应的问题类型，没有匹配的错误时按状态码使用
+func (s *Server) RegisterProblemStatus(code int, pt ProblemType) {
+	s.problems.mu.Lock()
+	defer s.problems.mu.Unlock()
+	if s.problems.status == nil {
+		s.problems.status = make(map[int]ProblemType)
+	}
+	s.problems.status[code] = pt
+}
+
+func (r *problemRegistry) lookup(err error, code int) (ProblemType, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, m := range r.mappings {
+		if errors.Is(err, m.err) {
+			return m.pt, true
+		}
+	}
+	pt, ok := r.status[code]
+	return pt, ok
+}
+
+// NewProblem 将错误转换为问题详情
+// HTTPError 的 Errno 输出到扩展字段 errno，ValidationError 的字段错误输出到扩展字段 errors；
+// Debug 模式下扩展字段 internal 包含完整的错误链
+func (s *Server) NewProblem(err error, c Context) *Problem {
+	p := &Problem{Extensions: Map{}}
+	code := http.StatusInternalServerError
+
+	var he *HTTPError
+	if errors.As(err, &he) {
+		if herr, ok := he.Internal.(*HTTPError); ok {
+			he = herr
+		}
+		code = he.Code
+		if he.Errno != he.Code {
+			p.Extensions["errno"] = he.Errno
+		}
+		switch m := s.message(he, c).(type) {
+		case string:
+			if m != http.StatusText(code) {
+				p.Detail = m
+			}
+		case nil:
+		default:
+			p.Extensions["message"] = m
+		}
+	}
+	var ve *ValidationError
+	if errors.As(err, &ve) {
+		code = http.StatusUnprocessableEntity
+		p.Detail = ""
+		p.Extensions["errors"] = ve.Fields
+	}
+
+	if pt, ok := s.problems.lookup(err, code); ok {
+		p.Type, p.Title = pt.Type, pt.Title
+		if pt.Status != 0 {
+			code = pt.Status
+		}
+	}
+	p.Status = code
+	if p.Type == "" {
+		p.Type = "about:blank"
+	}
+	if p.Title == "" {
+		p.Title = http.StatusText(code)
+	}
+	if r := c.Request(); r != nil && r.URL != nil {
+		p.Instance = r.URL.Path
+	}
+	if s.Debug {
+		var chain []string
+		for e := err; e != nil; e = errors.Unwrap(e) {
+			chain = append(chain, e.Error())
+		}
+		p.Extensions["internal"] = chain
+	}
+	return p
+}
+
+// ProblemHTTPErrorHandler 以 application/problem+json 格式输出错误，见 RFC 7807
+// 使用 s.HTTPErrorHandler = s.ProblemHTTPErrorHandler 启用
+func (s *Server) ProblemHTTPErrorHandler(err error, c Context) {
+	if c.ResponseWriter().Committed {
+		return
+	}
+	p := s.NewProblem(err, c)
+	if c.Request().Method == http.MethodHead {
+		if err := c.NoContent(p.Status); err != nil {
+			panic(err)
+		}
+		return
+	}
+	b, err := json.Marshal(p)
+	if err != nil {
+		panic(err)
+	}
+	if err := c.Blob(p.Status, MIMEApplicationProblemJSON, b); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestProblemHTTPErrorHandler(t *testing.T) {
+	errOutOfStock := errors.New("out of stock")
+
+	s := New()
+	s.HTTPErrorHandler = s.ProblemHTTPErrorHandler
+	s.RegisterProblem(errOutOfStock, ProblemType{Type: "https://example.com/probs/out-of-stock", Title: "Out of stock", Status: http.StatusConflict})
+	s.RegisterProblemStatus(http.StatusNotFound, ProblemType{Type: "https://example.com/probs/not-found"})
+	s.Get("/orders/:id", func(c Context) error {
+		id, _ := c.Param("id")
+		switch id {
+		case "1":
+			return fmt.Errorf("create order: %w", errOutOfStock)
+		case "2":
+			return NewValidationError().Add("amount", "must be positive").Add("sku", "required")
+		case "3":
+			return NewHTTPError(http.StatusForbidden, 10403, "order belongs to another user")
+		}
+		return ErrNotFound.SetInternal(errors.New("order not found in db"))
+	})
+
+	do := func(uri string) (int, Map) {
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, uri, nil))
+		if ct := w.Header().Get(HeaderContentType); ct != MIMEApplicationProblemJSON {
+			t.Fatalf("content type %q", ct)
+		}
+		var m Map
+		if err := json.Unmarshal(w.Body.Bytes(), &m); err != nil {
+			t.Fatal(err)
+		}
+		return w.Code, m
+	}
+
+	code, p := do("/orders/1")
+	if code != http.StatusConflict || p["type"] != "https://example.com/probs/out-of-stock" || p["title"] != "Out of stock" ||
+		p["status"] != float64(409) || p["instance"] != "/orders/1" {
+		t.Fatalf("unexpected problem %d %v", code, p)
+	}
+	if _, ok := p["internal"]; ok {
+		t.Fatal("internal error chain should only be included in debug mode")
+	}
+
+	code, p = do("/orders/2")
+	fields, _ := p["errors"].([]any)
+	if code != http.StatusUnprocessableEntity || p["type"] != "about:blank" || len(fields) != 2 ||
+		fields[0].(map[string]any)["field"] != "amount" {
+		t.Fatalf("unexpected problem %d %v", code, p)
+	}
+
+	code, p = do("/orders/3")
+	if code != http.StatusForbidden || p["errno"] != float64(10403) || p["detail"] != "order belongs to another user" {
+		t.Fatalf("unexpected problem %d %v", code, p)
+	}
+
+	s.Debug = true
+	code, p = do("/orders/4")
+	chain, _ := p["internal"].([]any)
+	if code != http.StatusNotFound || p["type"] != "https://example.com/probs/not-found" || p["title"] != "Not Found" ||
+		len(chain) != 2 || chain[1] != "order not found in db" {
+		t.Fatalf("unexpected problem %d %v", code, p)
+	}
+}
+
+type problemOrderController struct {
+	Ctx Context
+}
+
+type problemOrderRequest struct {
+	Amount int    `json:"amount"`
+	SKU    string `json:"sku"`
+}
+
+func (r *problemOrderRequest) Verify() error {
+	ve := NewValidationError()
+	if r.Amount <= 0 {
+		ve.Add("amount", "must be positive")
+	}
+	if r.SKU == "" {
+		ve.Add("sku", "required")
+	}
+	if len(ve.Fields) > 0 {
+		return ve
+	}
+	return nil
+}
+
+func (ctl *problemOrderController) Create(req *problemOrderRequest) (any, error) {
+	return Map{"sku": req.SKU}, nil
+}
+
+func TestProblemVerify(t *testing.T) {
+	s := New()
+	s.Post("/orders", Controller(problemOrderController{}, "Create"))
+
+	do := func() (int, Map) {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(`{"amount":0}`))
+		r.Header.Set(HeaderContentType, MIMEApplicationJSON)
+		s.ServeHTTP(w, r)
+		var m Map
+		_ = json.Unmarshal(w.Body.Bytes(), &m)
+		return w.Code, m
+	}
+
+	if code, m := do(); code != http.StatusUnprocessableEntity || m["message"] != http.StatusText(http.StatusUnprocessableEntity) {
+		t.Fatalf("unexpected %d %v", code, m)
+	}
+
+	s.HTTPErrorHandler = s.ProblemHTTPErrorHandler
+	code, p := do()
+	fields, _ := p["errors"].([]any)
+	if code != http.StatusUnprocessableEntity || len(fields) != 2 || fields[1].(map[string]any)["field"] != "sku" {
+		t.Fatalf("unexpected problem %d %v", code, p)
+	}
+}
+
+func TestHTTPErrorTranslate(t *testing.T) {
+	s := New()
+	s.Translator = func(r *http.Request, key string, params map[string]any) (string, bool) {
+		if key != "order.not_found" {
+			return "", false
+		}
+		if r.Header.Get(HeaderAcceptLanguage) == "zh" {
+			return fmt.Sprintf("订单 %v 不存在", params["id"]), true
+		}
+		return fmt.Sprintf("order %v not found", params["id"]), true
+	}
+	s.Get("/orders/:id", func(c Context) error {
+		id, _ := c.Param("id")
+		if id == "0" {
+			return ErrNotFound.WithKey("unknown.key")
+		}
+		return ErrNotFound.WithKey("order.not_found", map[string]any{"id": id})
+	})
+
+	do := func(uri, lang string) Map {
+		r := httptest.NewRequest(http.MethodGet, uri, nil)
+		r.Header.Set(HeaderAcceptLanguage, lang)
+		w := httptest.NewRecorder()
+		s.ServeHTTP(w, r)
+		var m Map
+		_ = json.Unmarshal(w.Body.Bytes(), &m)
+		return m
+	}
+	if m := do("/orders/7", "zh"); m["message"] != "订单 7 不存在" {
+		t.Fatalf("unexpected %v", m)
+	}
+	if m := do("/orders/7", "en"); m["message"] != "order 7 not found" {
+		t.Fatalf("unexpected %v", m)
+	}
+	// 无法翻译时使用 Message
+	if m := do("/orders/0", "en"); m["message"] != "Not Found" {
+		t.Fatalf("unexpected %v", m)
+	}
+
+	s.HTTPErrorHandler = s.ProblemHTTPErrorHandler
+	if m := do("/orders/7", "zh"); m["detail"] != "订单 7 不存在" {
+		t.Fatalf("unexpected %v", m)
+	}
+}
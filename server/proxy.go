@@ -0,0 +1,460 @@
+// 反向代理，支持负载均衡、主动和被动健康检查、路径重写、X-Forwarded-* 请求头和WebSocket透传
+package server
+
+import (
+	stdContext "context"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultProxyMaxFails      = 3
+	defaultProxyFailTimeout   = 10 * time.Second
+	defaultHealthCheckPeriod  = 10 * time.Second
+	defaultHealthCheckTimeout = 2 * time.Second
+	defaultHashReplicas       = 100
+)
+
+var ErrProxyNoTarget = errors.New("proxy requires at least one target")
+
+// ProxyTarget 代理的目标服务
+type ProxyTarget struct {
+	// Name 目标名称，一致性哈希按名称分布，默认为 URL.Host
+	Name string
+	// URL 目标地址，路径作为前缀，如 http://10.0.0.1:8080/base
+	URL *url.URL
+
+	conns     atomic.Int64
+	fails     atomic.Int32
+	downUntil atomic.Int64
+	unhealthy atomic.Bool
+}
+
+// NewProxyTarget 通过地址创建代理目标
+func NewProxyTarget(rawURL string) (*ProxyTarget, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy target: %s", rawURL)
+	}
+	return &ProxyTarget{Name: u.Host, URL: u}, nil
+}
+
+// Available 目标是否可用，主动检查失败或被动检查暂停期间不可用
+func (t *ProxyTarget) Available() bool {
+	return !t.unhealthy.Load() && time.Now().UnixNano() >= t.downUntil.Load()
+}
+
+// Conns 正在转发的请求数，包括WebSocket连接
+func (t *ProxyTarget) Conns() int64 {
+	return t.conns.Load()
+}
+
+// ProxyBalancer 负载均衡策略
+type ProxyBalancer interface {
+	// Next 从可用目标中选择一个，targets 不为空
+	Next(c Context, targets []*ProxyTarget) *ProxyTarget
+}
+
+type roundRobinBalancer struct {
+	i atomic.Uint64
+}
+
+// NewRoundRobinBalancer 轮询
+func NewRoundRobinBalancer() ProxyBalancer {
+	return &roundRobinBalancer{}
+}
+
+func (b *roundRobinBalancer) Next(c Context, targets []*ProxyTarget) *ProxyTarget {
+	return targets[(b.i.Add(1)-1)%uint64(len(targets))]
+}
+
+type leastConnBalancer struct {
+	rr roundRobinBalancer
+}
+
+// NewLeastConnBalancer 最少连接，连接数相同时轮询
+func NewLeastConnBalancer() ProxyBalancer {
+	return &leastConnBalancer{}
+}
+
+func (b *leastConnBalancer) Next(c Context, targets []*ProxyTarget) *ProxyTarget {
+	// 从轮询位置开始查找，避免连接数相同时总是选择第一个
+	start := int(b.rr.i.Add(1)-1) % len(targets)
+	best := targets[start]
+	for i := 1; i < len(targets); i++ {
+		t := targets[(start+i)%len(targets)]
+		if t.Conns() < best.Conns() {
+			best = t
+		}
+	}
+	return best
+}
+
+type hashNode struct {
+	hash   uint32
+	target *ProxyTarget
+}
+
+type consistentHashBalancer struct {
+	key      func(c Context) string
+	replicas int
+	mu       sync.RWMutex
+	names    string
+	ring     []hashNode
+}
+
+// NewConsistentHashBalancer 一致性哈希，相同key的请求转发到同一目标，目标增减时只影响少量key
+// key 为nil时使用客户端ip，replicas 为每个目标的虚拟节点数，默认100
+func NewConsistentHashBalancer(key func(c Context) string, replicas int) ProxyBalancer {
+	if key == nil {
+		key = func(c Context) string { return c.RealIP() }
+	}
+	if replicas <= 0 {
+		replicas = defaultHashReplicas
+	}
+	return &consistentHashBalancer{key: key, replicas: replicas}
+}
+
+func (b *consistentHashBalancer) Next(c Context, targets []*ProxyTarget) *ProxyTarget {
+	ring := b.build(targets)
+	h := crc32.ChecksumIEEE([]byte(b.key(c)))
+	i := sort.Search(len(ring), func(i int) bool { return ring[i].hash >= h })
+	if i == len(ring) {
+		i = 0
+	}
+	return ring[i].target
+}
+
+// build 可用目标变化时重建哈希环
+func (b *consistentHashBalancer) build(targets []*ProxyTarget) []hashNode {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Name
+	}
+	key := strings.Join(names, "\n")
+
+	b.mu.RLock()
+	if b.names == key {
+		ring := b.ring
+		b.mu.RUnlock()
+		return ring
+	}
+	b.mu.RUnlock()
+
+	ring := make([]hashNode, 0, len(targets)*b.replicas)
+	for _, t := range targets {
+		for i := 0; i < b.replicas; i++ {
+			ring = append(ring, hashNode{hash: crc32.ChecksumIEEE(fmt.Appendf(nil, "%s#%d", t.Name, i)), target: t})
+		}
+	}
+	slices.SortFunc(ring, func(a, b hashNode) int {
+		switch {
+		case a.hash < b.hash:
+			return -1
+		case a.hash > b.hash:
+			return 1
+		}
+		return strings.Compare(a.target.Name, b.target.Name)
+	})
+	b.mu.Lock()
+	b.names, b.ring = key, ring
+	b.mu.Unlock()
+	return ring
+}
+
+// ProxyHealthCheck 主动健康检查，定时请求目标的检查路径，返回2xx、3xx为健康
+type ProxyHealthCheck struct {
+	// Path 检查路径，如 /healthz，为空时不启用
+	Path string
+	// Interval 检查间隔，默认10秒
+	Interval time.Duration
+	// Timeout 单次检查的超时时间，默认2秒
+	Timeout time.Duration
+}
+
+type ProxyConfig struct {
+	// Targets 目标服务
+	Targets []*ProxyTarget
+	// Balancer 负载均衡策略，默认轮询
+	Balancer ProxyBalancer
+	// Transport 转发使用的 RoundTripper，默认 http.DefaultTransport
+	// 使用 httpclient.Manager.Transport 以支持自定义DNS和HTTPDNS解析
+	Transport http.RoundTripper
+	// Rewrite 路径重写规则，* 匹配任意字符，目标路径中 $1、$2 依次替换为匹配的内容，
+	// 如 "/legacy/*": "/$1"；按规则长度从长到短匹配，只应用第一个匹配的规则
+	Rewrite map[string]string
+	// PreserveHost 保留客户端请求的Host，默认使用目标的Host
+	PreserveHost bool
+	// MaxFails 连续失败次数达到后暂停转发到该目标，默认3，小于0时不启用被动检查
+	// 连接失败和502、503、504响应视为失败
+	MaxFails int
+	// FailTimeout 被动检查暂停转发的时间，默认10秒
+	FailTimeout time.Duration
+	// HealthCheck 主动健康检查
+	HealthCheck ProxyHealthCheck
+	// FlushInterval 响应刷新间隔，为0时不主动刷新，text/event-stream 总是立即刷新，小于0时每次写入后刷新
+	FlushInterval time.Duration
+	// ModifyResponse 修改目标服务的响应
+	ModifyResponse func(*http.Response) error
+}
+
+type rewriteRule struct {
+	re   *regexp.Regexp
+	repl string
+}
+
+// Proxy 反向代理
+type Proxy struct {
+	conf    ProxyConfig
+	rules   []rewriteRule
+	reverse *httputil.ReverseProxy
+	cancel  stdContext.CancelFunc
+	done    chan struct{}
+}
+
+type proxyCtxKey struct{}
+
+// proxyRequest 转发单个请求所需的信息，在 Rewrite 中使用
+type proxyRequest struct {
+	target       *ProxyTarget
+	path         string
+	forwardedFor string
+	realIP       string
+	proto        string
+	responded    bool
+	err          error
+}
+
+// NewProxy 创建反向代理，配置了主动健康检查时启动检查，不再使用时调用 Close 停止
+// 使用 g.Any("/legacy/*", proxy.Handle) 注册路由
+func NewProxy(conf ProxyConfig) (*Proxy, error) {
+	if len(conf.Targets) == 0 {
+		return nil, ErrProxyNoTarget
+	}
+	for _, t := range conf.Targets {
+		if t.URL == nil {
+			return nil, fmt.Errorf("invalid proxy target: %s", t.Name)
+		}
+		if t.Name == "" {
+			t.Name = t.URL.Host
+		}
+	}
+	if conf.Balancer == nil {
+		conf.Balancer = NewRoundRobinBalancer()
+	}
+	if conf.Transport == nil {
+		conf.Transport = http.DefaultTransport
+	}
+	if conf.MaxFails == 0 {
+		conf.MaxFails = defaultProxyMaxFails
+	}
+	if conf.FailTimeout <= 0 {
+		conf.FailTimeout = defaultProxyFailTimeout
+	}
+
+	p := &Proxy{conf: conf}
+	patterns := make([]string, 0, len(conf.Rewrite))
+	for pattern := range conf.Rewrite {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		expr := "^" + strings.ReplaceAll(regexp.QuoteMeta(pattern), `\*`, "(.*)") + "$"
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rewrite rule %s: %w", pattern, err)
+		}
+		p.rules = append(p.rules, rewriteRule{re: re, repl: conf.Rewrite[pattern]})
+	}
+
+	p.reverse = &httputil.ReverseProxy{
+		Rewrite:        p.rewrite,
+		Transport:      conf.Transport,
+		FlushInterval:  conf.FlushInterval,
+		ModifyResponse: p.modifyResponse,
+		ErrorHandler:   p.errorHandler,
+	}
+
+	if conf.HealthCheck.Path != "" {
+		if conf.HealthCheck.Interval <= 0 {
+			p.conf.HealthCheck.Interval = defaultHealthCheckPeriod
+		}
+		if conf.HealthCheck.Timeout <= 0 {
+			p.conf.HealthCheck.Timeout = defaultHealthCheckTimeout
+		}
+		var ctx stdContext.Context
+		ctx, p.cancel = stdContext.WithCancel(stdContext.Background())
+		p.done = make(chan struct{})
+		go p.healthCheck(ctx)
+	}
+	return p, nil
+}
+
+// Targets 所有目标
+func (p *Proxy) Targets() []*ProxyTarget {
+	return p.conf.Targets
+}
+
+// Close 停止主动健康检查
+func (p *Proxy) Close() error {
+	if p.cancel != nil {
+		p.cancel()
+		<-p.done
+	}
+	return nil
+}
+
+// Handle 转发请求，没有可用目标时返回503，目标不可达时返回502
+func (p *Proxy) Handle(c Context) error {
+	available := make([]*ProxyTarget, 0, len(p.conf.Targets))
+	for _, t := range p.conf.Targets {
+		if t.Available() {
+			available = append(available, t)
+		}
+	}
+	if len(available) == 0 {
+		return ErrServiceUnavailable
+	}
+	target := p.conf.Balancer.Next(c, available)
+
+	r := c.Request()
+	pr := &proxyRequest{
+		target: target,
+		path:   p.rewritePath(r.URL.Path),
+		realIP: c.RealIP(),
+		proto:  c.Scheme(),
+	}
+	// 只有来自可信代理的请求才保留原有的代理链
+	remote := remoteIP(r.RemoteAddr)
+	pr.forwardedFor = remote
+	if xff := r.Header.Values(HeaderXForwardedFor); len(xff) > 0 && c.c().s().IsTrustedProxy(remote) {
+		pr.forwardedFor = strings.Join(xff, ", ") + ", " + remote
+	}
+
+	target.conns.Add(1)
+	defer target.conns.Add(-1)
+	p.reverse.ServeHTTP(c.ResponseWriter(), r.WithContext(stdContext.WithValue(r.Context(), proxyCtxKey{}, pr)))
+	if pr.err != nil && !c.ResponseWriter().Committed {
+		return ErrBadGateway.SetInternal(pr.err)
+	}
+	return nil
+}
+
+func (p *Proxy) rewritePath(path string) string {
+	for _, rule := range p.rules {
+		if rule.re.MatchString(path) {
+			return rule.re.ReplaceAllString(path, rule.repl)
+		}
+	}
+	return path
+}
+
+func (p *Proxy) rewrite(r *httputil.ProxyRequest) {
+	pr := r.In.Context().Value(proxyCtxKey{}).(*proxyRequest)
+	r.Out.URL.Path = pr.path
+	r.Out.URL.RawPath = ""
+	r.SetURL(pr.target.URL)
+	if p.conf.PreserveHost {
+		r.Out.Host = r.In.Host
+	}
+	r.Out.Header.Set(HeaderXForwardedFor, pr.forwardedFor)
+	r.Out.Header.Set(HeaderXForwardedHost, r.In.Host)
+	r.Out.Header.Set(HeaderXForwardedProto, pr.proto)
+	r.Out.Header.Set(HeaderXRealIP, pr.realIP)
+}
+
+func (p *Proxy) modifyResponse(resp *http.Response) error {
+	pr := resp.Request.Context().Value(proxyCtxKey{}).(*proxyRequest)
+	pr.responded = true
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		p.fail(pr.target)
+	default:
+		pr.target.fails.Store(0)
+	}
+	if p.conf.ModifyResponse != nil {
+		return p.conf.ModifyResponse(resp)
+	}
+	return nil
+}
+
+func (p *Proxy) errorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	pr := r.Context().Value(proxyCtxKey{}).(*proxyRequest)
+	pr.err = err
+	// 客户端取消的请求和 ModifyResponse 返回的错误不计为目标失败
+	if !pr.responded && r.Context().Err() == nil {
+		p.fail(pr.target)
+	}
+}
+
+// fail 被动检查，连续失败达到 MaxFails 次后暂停转发 FailTimeout
+func (p *Proxy) fail(t *ProxyTarget) {
+	if p.conf.MaxFails < 0 {
+		return
+	}
+	if int(t.fails.Add(1)) >= p.conf.MaxFails {
+		t.fails.Store(0)
+		t.downUntil.Store(time.Now().Add(p.conf.FailTimeout).UnixNano())
+	}
+}
+
+func (p *Proxy) healthCheck(ctx stdContext.Context) {
+	defer close(p.done)
+	client := &http.Client{
+		Transport: p.conf.Transport,
+		Timeout:   p.conf.HealthCheck.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	ticker := time.NewTicker(p.conf.HealthCheck.Interval)
+	defer ticker.Stop()
+	for {
+		var wg sync.WaitGroup
+		for _, t := range p.conf.Targets {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				t.unhealthy.Store(!p.check(ctx, client, t))
+			}()
+		}
+		wg.Wait()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Proxy) check(ctx stdContext.Context, client *http.Client, t *ProxyTarget) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.URL.JoinPath(p.conf.HealthCheck.Path).String(), nil)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode < http.StatusBadRequest
+}
@@ -0,0 +1,253 @@
+package server
+
+import (
+	stdContext "context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+)
+
+func newProxyBackend(t *testing.T, name string) (*httptest.Server, *ProxyTarget) {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s %s %s %s %s", name, r.URL.Path, r.Header.Get(HeaderXForwardedFor), r.Header.Get(HeaderXForwardedHost), r.Header.Get(HeaderXForwardedProto))
+	}))
+	t.Cleanup(srv.Close)
+	target, err := NewProxyTarget(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.Name = name
+	return srv, target
+}
+
+func proxyGet(t *testing.T, s *Server, path, remoteAddr string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if remoteAddr != "" {
+		req.RemoteAddr = remoteAddr
+	}
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func TestProxyRoundRobinRewrite(t *testing.T) {
+	_, a := newProxyBackend(t, "a")
+	_, b := newProxyBackend(t, "b")
+	p, err := NewProxy(ProxyConfig{
+		Targets: []*ProxyTarget{a, b},
+		Rewrite: map[string]string{"/legacy/*": "/v1/$1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	s.Any("/legacy/*", p.Handle)
+
+	want := []string{
+		"a /v1/users 192.0.2.1 example.com http",
+		"b /v1/users 192.0.2.1 example.com http",
+		"a /v1/users 192.0.2.1 example.com http",
+	}
+	for i, w := range want {
+		code, body := proxyGet(t, s, "/legacy/users", "")
+		if code != http.StatusOK || body != w {
+			t.Fatalf("request %d: got %d %q, want %q", i, code, body, w)
+		}
+	}
+}
+
+func TestProxyForwardedForTrusted(t *testing.T) {
+	_, a := newProxyBackend(t, "a")
+	p, err := NewProxy(ProxyConfig{Targets: []*ProxyTarget{a}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	if err := s.SetTrustedProxies("10.0.0.0/8"); err != nil {
+		t.Fatal(err)
+	}
+	s.Any("/*", p.Handle)
+
+	for _, tt := range []struct {
+		remoteAddr string
+		want       string
+	}{
+		{"10.0.0.1:1234", "a /x 198.51.100.7, 10.0.0.1 example.com https"},
+		{"203.0.113.9:1234", "a /x 203.0.113.9 example.com http"},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/x", nil)
+		req.RemoteAddr = tt.remoteAddr
+		req.Header.Set(HeaderXForwardedFor, "198.51.100.7")
+		req.Header.Set(HeaderXForwardedProto, "https")
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Body.String() != tt.want {
+			t.Errorf("remote %s: got %q, want %q", tt.remoteAddr, rec.Body.String(), tt.want)
+		}
+	}
+}
+
+func TestProxyPassiveHealthCheck(t *testing.T) {
+	_, a := newProxyBackend(t, "a")
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	b, _ := NewProxyTarget(down.URL)
+	b.Name = "b"
+	down.Close()
+
+	p, err := NewProxy(ProxyConfig{Targets: []*ProxyTarget{a, b}, MaxFails: 2, FailTimeout: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	s.Any("/*", p.Handle)
+
+	var bad int
+	for range 10 {
+		if code, _ := proxyGet(t, s, "/x", ""); code == http.StatusBadGateway {
+			bad++
+		}
+	}
+	if bad != 2 {
+		t.Fatalf("got %d bad gateway responses, want 2", bad)
+	}
+	if b.Available() || !a.Available() {
+		t.Fatalf("available a=%v b=%v", a.Available(), b.Available())
+	}
+
+	a.downUntil.Store(time.Now().Add(time.Minute).UnixNano())
+	if code, _ := proxyGet(t, s, "/x", ""); code != http.StatusServiceUnavailable {
+		t.Fatalf("got %d with no available target, want 503", code)
+	}
+}
+
+func TestProxyActiveHealthCheck(t *testing.T) {
+	var healthy atomic.Bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/base/healthz" && !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+	target, _ := NewProxyTarget(srv.URL + "/base")
+
+	p, err := NewProxy(ProxyConfig{
+		Targets:     []*ProxyTarget{target},
+		HealthCheck: ProxyHealthCheck{Path: "/healthz", Interval: 10 * time.Millisecond},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+
+	waitFor := func(want bool) {
+		t.Helper()
+		deadline := time.Now().Add(time.Second)
+		for target.Available() != want {
+			if time.Now().After(deadline) {
+				t.Fatalf("target available = %v, want %v", !want, want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor(false)
+	healthy.Store(true)
+	waitFor(true)
+}
+
+func TestProxyBalancers(t *testing.T) {
+	targets := make([]*ProxyTarget, 4)
+	for i := range targets {
+		targets[i], _ = NewProxyTarget(fmt.Sprintf("http://10.0.0.%d", i+1))
+	}
+	s := New()
+	newCtx := func(ip string) Context {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = ip + ":1234"
+		return s.NewContext(req, httptest.NewRecorder())
+	}
+
+	lc := NewLeastConnBalancer()
+	targets[0].conns.Store(3)
+	targets[1].conns.Store(1)
+	targets[2].conns.Store(2)
+	targets[3].conns.Store(1)
+	for range 4 {
+		if got := lc.Next(newCtx("192.0.2.1"), targets); got != targets[1] && got != targets[3] {
+			t.Fatalf("least conn chose %s", got.Name)
+		}
+	}
+
+	ch := NewConsistentHashBalancer(nil, 0)
+	assigned := make(map[string]*ProxyTarget)
+	for i := range 200 {
+		ip := fmt.Sprintf("198.51.100.%d", i)
+		assigned[ip] = ch.Next(newCtx(ip), targets)
+		if ch.Next(newCtx(ip), targets) != assigned[ip] {
+			t.Fatalf("%s is not sticky", ip)
+		}
+	}
+	// 移除一个目标后，只有原来分配到该目标的key会改变
+	removed := targets[2]
+	remaining := []*ProxyTarget{targets[0], targets[1], targets[3]}
+	for ip, prev := range assigned {
+		got := ch.Next(newCtx(ip), remaining)
+		if prev != removed && got != prev {
+			t.Fatalf("%s moved from %s to %s", ip, prev.Name, got.Name)
+		}
+	}
+}
+
+func TestProxyWebSocket(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := websocket.Accept(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.CloseNow()
+		typ, msg, err := conn.Read(r.Context())
+		if err != nil {
+			return
+		}
+		_ = conn.Write(r.Context(), typ, append([]byte(r.URL.Path+" "), msg...))
+	}))
+	defer backend.Close()
+	target, _ := NewProxyTarget(backend.URL)
+
+	p, err := NewProxy(ProxyConfig{Targets: []*ProxyTarget{target}, Rewrite: map[string]string{"/ws/*": "/$1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	s.Any("/ws/*", p.Handle)
+	front := httptest.NewServer(s)
+	defer front.Close()
+
+	ctx, cancel := stdContext.WithTimeout(stdContext.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+front.URL[len("http"):]+"/ws/echo", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseNow()
+	if err := conn.Write(ctx, websocket.MessageText, []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	_, msg, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(msg) != "/echo hello" {
+		t.Fatalf("got %q", msg)
+	}
+	if target.Conns() != 1 {
+		t.Fatalf("conns = %d during websocket session, want 1", target.Conns())
+	}
+	_ = conn.Close(websocket.StatusNormalClosure, "")
+}
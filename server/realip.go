@@ -0,0 +1,245 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// HeaderForwarded RFC 7239 Forwarded header
+const HeaderForwarded = "Forwarded"
+
+// SetTrustedProxies 设置可信代理的CIDR列表，单个IP按/32或/128处理
+// 只有来自可信代理的请求才会解析 Forwarded、X-Forwarded-For、X-Real-IP 等头
+func (s *Server) SetTrustedProxies(cidrs ...string) error {
+	prefixes := make([]netip.Prefix, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		cidr = strings.TrimSpace(cidr)
+		if cidr == "" {
+			continue
+		}
+		if !strings.Contains(cidr, "/") {
+			addr, err := netip.ParseAddr(cidr)
+			if err != nil {
+				return err
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(cidr)
+		if err != nil {
+			return err
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	s.trustedProxies = prefixes
+	return nil
+}
+
+// IsTrustedProxy 判断ip是否属于可信代理
+func (s *Server) IsTrustedProxy(ip string) bool {
+	addr, ok := parseIP(ip)
+	if !ok {
+		return false
+	}
+	for _, prefix := range s.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedElement Forwarded 头中的单个节点
+type forwardedElement struct {
+	For   string
+	Proto string
+}
+
+// parseForwarded 解析 RFC 7239 Forwarded 头，按出现顺序返回所有节点
+func parseForwarded(values []string) []forwardedElement {
+	var list []forwardedElement
+	for _, value := range values {
+		for _, element := range splitQuoted(value, ',') {
+			var fe forwardedElement
+			for _, pair := range splitQuoted(element, ';') {
+				k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+				v = strings.Trim(strings.TrimSpace(v), `"`)
+				switch strings.ToLower(strings.TrimSpace(k)) {
+				case "for":
+					fe.For = stripForwardedPort(v)
+				case "proto":
+					fe.Proto = strings.ToLower(v)
+				}
+			}
+			list = append(list, fe)
+		}
+	}
+	return list
+}
+
+// splitQuoted 按分隔符切分，忽略引号内的分隔符
+func splitQuoted(s string, sep byte) []string {
+	var list []string
+	quoted := false
+	start := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				list = append(list, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(list, s[start:])
+}
+
+// stripForwardedPort 去除 Forwarded for 节点中的端口和方括号，如 "[2001:db8::1]:4711"
+func stripForwardedPort(v string) string {
+	if strings.HasPrefix(v, "[") {
+		if i := strings.IndexByte(v, ']'); i > 0 {
+			return v[1:i]
+		}
+		return v
+	}
+	if strings.Count(v, ":") == 1 {
+		host, _, _ := strings.Cut(v, ":")
+		return host
+	}
+	return v
+}
+
+func parseIP(ip string) (netip.Addr, bool) {
+	ip = strings.TrimSpace(ip)
+	ip = strings.TrimPrefix(ip, "[")
+	ip = strings.TrimSuffix(ip, "]")
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// remoteIP 获取tcp连接的对端ip
+func remoteIP(remoteAddr string) string {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		return remoteAddr
+	}
+	return host
+}
+
+// clientIP 从右往左遍历代理链，跳过可信代理，返回第一个不可信的节点
+// 全部节点都可信时返回最左侧的节点
+func (s *Server) clientIP(hops []string) (string, int) {
+	for i := len(hops) - 1; i >= 0; i-- {
+		if _, ok := parseIP(hops[i]); !ok {
+			// 无法解析的节点（如 unknown 或混淆标识），不再继续信任左侧的内容
+			return "", i
+		}
+		if !s.IsTrustedProxy(hops[i]) {
+			return hops[i], i
+		}
+	}
+	if len(hops) > 0 {
+		return hops[0], 0
+	}
+	return "", -1
+}
+
+// realIP 解析请求的客户端ip和协议
+func (s *Server) realIP(c *context) (string, string) {
+	r := c.request
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	remote := remoteIP(r.RemoteAddr)
+	if !s.IsTrustedProxy(remote) {
+		return remote, scheme
+	}
+
+	if s.ClientIPHeader != "" {
+		if ip, ok := parseIP(r.Header.Get(s.ClientIPHeader)); ok {
+			return ip.String(), forwardedScheme(r.Header, scheme, -1, 0)
+		}
+	}
+
+	if values := r.Header.Values(HeaderForwarded); len(values) > 0 {
+		elements := parseForwarded(values)
+		hops := make([]string, 0, len(elements)+1)
+		for _, fe := range elements {
+			hops = append(hops, fe.For)
+		}
+		hops = append(hops, remote)
+		ip, i := s.clientIP(hops)
+		if ip == "" {
+			return remote, scheme
+		}
+		// 使用客户端所连接的第一个代理记录的协议
+		if i < len(elements) && elements[i].Proto != "" {
+			scheme = elements[i].Proto
+		}
+		return ip, scheme
+	}
+
+	if values := r.Header.Values(HeaderXForwardedFor); len(values) > 0 {
+		var hops []string
+		for _, value := range values {
+			for _, hop := range strings.Split(value, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+		hops = append(hops, remote)
+		if ip, i := s.clientIP(hops); ip != "" {
+			return ip, forwardedScheme(r.Header, scheme, i, len(hops)-1)
+		}
+		return remote, scheme
+	}
+
+	if ip, ok := parseIP(r.Header.Get(HeaderXRealIP)); ok {
+		return ip.String(), forwardedScheme(r.Header, scheme, -1, 0)
+	}
+	return remote, forwardedScheme(r.Header, scheme, -1, 0)
+}
+
+// forwardedScheme 从可信代理设置的 X-Forwarded-* 头中获取协议
+// 多级代理时头为逗号分隔的列表，最左侧的值可能由客户端伪造：
+// 列表与 X-Forwarded-For 的 hops 个节点一一对应时取客户端节点 hop 对应的值，否则取最近的代理设置的最后一个值
+func forwardedScheme(header http.Header, scheme string, hop, hops int) string {
+	get := func(key string) string {
+		var list []string
+		for _, value := range header.Values(key) {
+			for _, v := range strings.Split(value, ",") {
+				list = append(list, strings.ToLower(strings.TrimSpace(v)))
+			}
+		}
+		if len(list) == 0 {
+			return ""
+		}
+		if hop >= 0 && len(list) == hops {
+			return list[hop]
+		}
+		return list[len(list)-1]
+	}
+	if proto := get(HeaderXForwardedProto); proto != "" {
+		return proto
+	}
+	if proto := get(HeaderXForwardedProtocol); proto != "" {
+		return proto
+	}
+	if get(HeaderXForwardedSsl) == "on" {
+		return "https"
+	}
+	if proto := get(HeaderXUrlScheme); proto != "" {
+		return proto
+	}
+	return scheme
+}
@@ -0,0 +1,113 @@
+package server
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextRealIP(t *testing.T) {
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     map[string]string
+		tls        bool
+		wantIP     string
+		wantScheme string
+	}{
+		{
+			name:       "untrusted remote ignores headers",
+			remoteAddr: "203.0.113.9:1234",
+			header:     map[string]string{HeaderXForwardedFor: "1.1.1.1", HeaderXForwardedProto: "https"},
+			wantIP:     "203.0.113.9",
+			wantScheme: "http",
+		},
+		{
+			name:       "xff skips trusted hops from the right",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{HeaderXForwardedFor: "6.6.6.6, 198.51.100.7, 10.0.0.2", HeaderXForwardedProto: "https"},
+			wantIP:     "198.51.100.7",
+			wantScheme: "https",
+		},
+		{
+			name:       "xff proto picks entry of client hop",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{HeaderXForwardedFor: "6.6.6.6, 198.51.100.7, 10.0.0.2", HeaderXForwardedProto: "https, http, https"},
+			wantIP:     "198.51.100.7",
+			wantScheme: "http",
+		},
+		{
+			name:       "xff proto ignores spoofed leftmost entry",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{HeaderXForwardedFor: "198.51.100.7, 10.0.0.2", HeaderXForwardedProto: "https, https, http"},
+			wantIP:     "198.51.100.7",
+			wantScheme: "http",
+		},
+		{
+			name:       "xff all trusted returns leftmost",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{HeaderXForwardedFor: "10.0.0.3, 10.0.0.2"},
+			wantIP:     "10.0.0.3",
+			wantScheme: "http",
+		},
+		{
+			name:       "forwarded header",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{HeaderForwarded: `for=6.6.6.6;proto=http, for="[2001:db8::1]:4711";proto=https, for=10.0.0.2`},
+			wantIP:     "2001:db8::1",
+			wantScheme: "https",
+		},
+		{
+			name:       "forwarded unknown hop stops the walk",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{HeaderForwarded: `for=198.51.100.7, for=unknown`},
+			wantIP:     "10.0.0.1",
+			wantScheme: "http",
+		},
+		{
+			name:       "client ip header from trusted proxy",
+			remoteAddr: "10.0.0.1:1234",
+			header:     map[string]string{"Eo-Client-Ip": "198.51.100.8", HeaderXForwardedFor: "6.6.6.6"},
+			wantIP:     "198.51.100.8",
+			wantScheme: "http",
+		},
+		{
+			name:       "tls without headers",
+			remoteAddr: "203.0.113.9:1234",
+			tls:        true,
+			wantIP:     "203.0.113.9",
+			wantScheme: "https",
+		},
+	}
+
+	s := New()
+	s.ClientIPHeader = "Eo-Client-Ip"
+	if err := s.SetTrustedProxies("10.0.0.0/8", "127.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.tls {
+				r.TLS = &tls.ConnectionState{}
+			}
+			for k, v := range tt.header {
+				r.Header.Set(k, v)
+			}
+			c := s.newContext(r, httptest.NewRecorder())
+			if got := c.RealIP(); got != tt.wantIP {
+				t.Errorf("context.RealIP() = %v, want %v", got, tt.wantIP)
+			}
+			if got := c.Scheme(); got != tt.wantScheme {
+				t.Errorf("context.Scheme() = %v, want %v", got, tt.wantScheme)
+			}
+		})
+	}
+
+	if err := s.SetTrustedProxies("10.0.0.0/33"); err == nil {
+		t.Errorf("SetTrustedProxies() expected error for invalid cidr")
+	}
+}
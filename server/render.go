@@ -0,0 +1,249 @@
+package server
+
+// 模板渲染，默认实现基于 html/template，支持布局、公共模板和Debug模式下自动重新加载
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	ErrTemplateNotFound = errors.New("template not found")
+	ErrTemplateFS       = errors.New("template fs required")
+)
+
+// Renderer 模板渲染器
+type Renderer interface {
+	// Render 渲染模板，c 可能为nil，如渲染邮件内容
+	Render(w io.Writer, name string, data any, c Context) error
+}
+
+type TemplateConfig struct {
+	// FS 模板所在的文件系统，如 embed.FS 或 os.DirFS("views")，模板名为文件在FS中的路径，如 users/index.html
+	FS fs.FS
+	// Extensions 模板文件扩展名，默认 .html
+	Extensions []string
+	// Layout 默认布局模板，如 layouts/base.html，为空时不使用布局
+	// 使用布局时执行布局模板，页面通过 {{define "content"}} 覆盖布局中的 {{block "content" .}}
+	Layout string
+	// Layouts 按模板名前缀指定布局，最长前缀优先，值为空时不使用布局，如 {"emails/": ""}
+	Layouts map[string]string
+	// Partials 公共模板的glob，每个页面都可以引用，如 partials/*.html
+	Partials []string
+	// Funcs 自定义模板函数，同名时覆盖内置函数
+	Funcs template.FuncMap
+	// BaseURL url 和 path 函数生成的地址前缀，如 /admin 或 https://example.com
+	BaseURL string
+	// Reload 每次渲染时重新加载模板，为false时在 Server.Debug 模式下重新加载
+	Reload bool
+	// Delims 模板分隔符，默认 {{ }}
+	Delims [2]string
+}
+
+// TemplateRenderer html/template 模板渲染器
+type TemplateRenderer struct {
+	conf      TemplateConfig
+	funcs     template.FuncMap
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+}
+
+// NewTemplateRenderer 创建模板渲染器，并加载所有模板，模板有错误时返回错误
+func NewTemplateRenderer(conf TemplateConfig) (*TemplateRenderer, error) {
+	if conf.FS == nil {
+		return nil, ErrTemplateFS
+	}
+	if len(conf.Extensions) == 0 {
+		conf.Extensions = []string{".html"}
+	}
+	r := &TemplateRenderer{conf: conf, funcs: templateFuncs(conf.BaseURL)}
+	for k, fn := range conf.Funcs {
+		r.funcs[k] = fn
+	}
+	if err := r.Load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Load 重新加载所有模板
+func (r *TemplateRenderer) Load() error {
+	var names []string
+	err := fs.WalkDir(r.conf.FS, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && slices.Contains(r.conf.Extensions, path.Ext(name)) {
+			names = append(names, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	templates := make(map[string]*template.Template, len(names))
+	for _, name := range names {
+		t, err := r.parse(name)
+		if err != nil {
+			return err
+		}
+		templates[name] = t
+	}
+	r.mu.Lock()
+	r.templates = templates
+	r.mu.Unlock()
+	return nil
+}
+
+// layout 模板使用的布局
+func (r *TemplateRenderer) layout(name string) string {
+	layout := r.conf.Layout
+	prefixes := make([]string, 0, len(r.conf.Layouts))
+	for prefix := range r.conf.Layouts {
+		if strings.HasPrefix(name, prefix) {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	if len(prefixes) > 0 {
+		sort.Slice(prefixes, func(i, j int) bool { return len(prefixes[i]) > len(prefixes[j]) })
+		layout = r.conf.Layouts[prefixes[0]]
+	}
+	if layout == name {
+		return ""
+	}
+	return layout
+}
+
+// parse 按 布局、公共模板、页面 的顺序解析，页面中的定义覆盖布局中的同名模板
+func (r *TemplateRenderer) parse(name string) (*template.Template, error) {
+	var files []string
+	if layout := r.layout(name); layout != "" {
+		files = append(files, layout)
+	}
+	for _, pattern := range r.conf.Partials {
+		matches, err := fs.Glob(r.conf.FS, pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range matches {
+			if m != name && !slices.Contains(files, m) {
+				files = append(files, m)
+			}
+		}
+	}
+	files = append(files, name)
+
+	// 根模板只用于关联所有模板，不直接执行
+	t := template.New("").Funcs(r.funcs)
+	if r.conf.Delims[0] != "" {
+		t.Delims(r.conf.Delims[0], r.conf.Delims[1])
+	}
+	for _, file := range files {
+		b, err := fs.ReadFile(r.conf.FS, file)
+		if err != nil {
+			return nil, err
+		}
+		// 模板名使用完整路径，避免不同目录下的同名文件冲突
+		if _, err := t.New(file).Parse(string(b)); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
+
+// Render 实现 Renderer，Reload 或 Server.Debug 时每次重新解析模板
+func (r *TemplateRenderer) Render(w io.Writer, name string, data any, c Context) error {
+	if r.conf.Reload || (c != nil && c.IsDebug()) {
+		t, err := r.parse(name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+			}
+			return err
+		}
+		return r.execute(w, t, name, data)
+	}
+	return r.Execute(w, name, data)
+}
+
+// Execute 使用已加载的模板渲染
+func (r *TemplateRenderer) Execute(w io.Writer, name string, data any) error {
+	r.mu.RLock()
+	t, ok := r.templates[name]
+	r.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrTemplateNotFound, name)
+	}
+	return r.execute(w, t, name, data)
+}
+
+func (r *TemplateRenderer) execute(w io.Writer, t *template.Template, name string, data any) error {
+	if layout := r.layout(name); layout != "" {
+		return t.ExecuteTemplate(w, layout, data)
+	}
+	return t.ExecuteTemplate(w, name, data)
+}
+
+// templateFuncs 内置模板函数
+//
+//	url   {{url "/users" "page" 2}}         => /users?page=2
+//	path  {{path "users" .ID "edit"}}       => /users/12/edit，每段单独转义
+//	query {{query .Keyword}}                => 转义为query参数
+//	dict  {{template "card" dict "title" .Title "user" .User}}
+func templateFuncs(baseURL string) template.FuncMap {
+	baseURL = strings.TrimRight(baseURL, "/")
+	return template.FuncMap{
+		"url": func(p string, pairs ...any) (string, error) {
+			if len(pairs)%2 != 0 {
+				return "", errors.New("url: query requires key value pairs")
+			}
+			u := p
+			if !strings.Contains(p, "://") {
+				u = baseURL + p
+			}
+			if len(pairs) == 0 {
+				return u, nil
+			}
+			q := url.Values{}
+			for i := 0; i < len(pairs); i += 2 {
+				q.Add(fmt.Sprint(pairs[i]), fmt.Sprint(pairs[i+1]))
+			}
+			sep := "?"
+			if strings.Contains(u, "?") {
+				sep = "&"
+			}
+			return u + sep + q.Encode(), nil
+		},
+		"path": func(segments ...any) string {
+			list := make([]string, 0, len(segments))
+			for _, seg := range segments {
+				list = append(list, url.PathEscape(fmt.Sprint(seg)))
+			}
+			return baseURL + "/" + strings.Join(list, "/")
+		},
+		"query": url.QueryEscape,
+		"dict": func(pairs ...any) (map[string]any, error) {
+			if len(pairs)%2 != 0 {
+				return nil, errors.New("dict: requires key value pairs")
+			}
+			m := make(map[string]any, len(pairs)/2)
+			for i := 0; i < len(pairs); i += 2 {
+				key, ok := pairs[i].(string)
+				if !ok {
+					return nil, fmt.Errorf("dict: key %v is not a string", pairs[i])
+				}
+				m[key] = pairs[i+1]
+			}
+			return m, nil
+		},
+	}
+}
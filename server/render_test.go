@@ -0,0 +1,107 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestTemplateRenderer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layouts/base.html":   {Data: []byte(`<title>{{block "title" .}}default{{end}}</title><main>{{block "content" .}}{{end}}</main>`)},
+		"partials/user.html":  {Data: []byte(`{{define "user"}}<a href="{{path "users" .id}}">{{.name}}</a>{{end}}`)},
+		"users/index.html":    {Data: []byte(`{{define "title"}}Users{{end}}{{define "content"}}{{range .}}{{template "user" dict "id" .ID "name" .Name}}{{end}}{{end}}`)},
+		"admin/index.html":    {Data: []byte(`{{define "content"}}<a href="{{url "/search" "q" "a b"}}">search</a>{{end}}`)},
+		"emails/welcome.html": {Data: []byte(`Hi {{.}}`)},
+	}
+	r, err := NewTemplateRenderer(TemplateConfig{
+		FS:       fsys,
+		Layout:   "layouts/base.html",
+		Layouts:  map[string]string{"emails/": ""},
+		Partials: []string{"partials/*.html"},
+		BaseURL:  "/admin/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New()
+	s.Renderer = r
+	s.Get("/users", func(c Context) error {
+		return c.Render(http.StatusOK, "users/index.html", []struct {
+			ID   int
+			Name string
+		}{{1, "<Tom>"}, {2, "Ann"}})
+	})
+	s.Get("/search", func(c Context) error {
+		return c.Render(http.StatusOK, "admin/index.html", nil)
+	})
+	s.Get("/missing", func(c Context) error {
+		return c.Render(http.StatusOK, "missing.html", nil)
+	})
+
+	for _, tt := range []struct {
+		path string
+		code int
+		want string
+	}{
+		{"/users", http.StatusOK, `<title>Users</title><main><a href="/admin/users/1">&lt;Tom&gt;</a><a href="/admin/users/2">Ann</a></main>`},
+		{"/search", http.StatusOK, `<title>default</title><main><a href="/admin/search?q=a&#43;b">search</a></main>`},
+		{"/missing", http.StatusInternalServerError, ""},
+	} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if rec.Code != tt.code {
+			t.Fatalf("%s: status = %d, want %d", tt.path, rec.Code, tt.code)
+		}
+		if tt.want != "" && rec.Body.String() != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.path, rec.Body.String(), tt.want)
+		}
+	}
+
+	var sb strings.Builder
+	if err := r.Render(&sb, "emails/welcome.html", "Tom", nil); err != nil || sb.String() != "Hi Tom" {
+		t.Fatalf("email: %q, %v", sb.String(), err)
+	}
+	if err := r.Execute(&sb, "missing.html", nil); !errors.Is(err, ErrTemplateNotFound) {
+		t.Fatalf("missing: %v", err)
+	}
+}
+
+func TestTemplateRendererReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "index.html")
+	if err := os.WriteFile(file, []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewTemplateRenderer(TemplateConfig{FS: os.DirFS(dir)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	s.Renderer = r
+	s.Get("/", func(c Context) error {
+		return c.Render(http.StatusOK, "index.html", nil)
+	})
+	render := func() string {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+		return rec.Body.String()
+	}
+
+	if err := os.WriteFile(file, []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := render(); got != "v1" {
+		t.Fatalf("cached render = %q, want v1", got)
+	}
+	s.Debug = true
+	if got := render(); got != "v2" {
+		t.Fatalf("debug render = %q, want v2", got)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/netip"
 	"runtime/debug"
 	"sync"
 )
@@ -38,6 +39,10 @@ type Server struct {
 	notFoundHandler  HandlerFunc
 	pool             sync.Pool
 	eventManager     *EventManager
+	poller           *poller
+	routes           map[pair]*RouteInfo
+	trustedProxies   []netip.Prefix
+	problems         problemRegistry
 	Http             *http.Server
 	Listener         net.Listener
 	Debug            bool
@@ -45,6 +50,12 @@ type Server struct {
 	HTTPOKHandler    HTTPOKHandler
 	Logger           *log.Logger
 	ListenerNetwork  string
+	// ClientIPHeader 可信代理（如CDN）写入客户端ip的请求头，仅在请求来自可信代理时生效
+	ClientIPHeader string
+	// Translator 按请求语言翻译 HTTPError 的消息key，为nil时直接使用 Message，见 i18n.Bundle.Translator
+	Translator func(r *http.Request, key string, params map[string]any) (string, bool)
+	// Renderer 模板渲染器，用于 Context.Render，见 NewTemplateRenderer
+	Renderer Renderer
 }
 
 var (
@@ -70,10 +81,12 @@ func New() (s *Server) {
 	s = &Server{
 		Http:            new(http.Server),
 		maxParam:        new(int),
+		routes:          make(map[pair]*RouteInfo),
 		ListenerNetwork: "tcp",
 	}
 	s.common.add = s.Add
 	s.eventManager = &EventManager{server: s}
+	s.poller = newPoller()
 	s.Http.Handler = s
 	s.HTTPOKHandler = s.DefaultHTTPOKHandler
 	s.HTTPErrorHandler = s.DefaultHTTPErrorHandler
@@ -96,6 +109,11 @@ func (s *Server) newContext(r *http.Request, w http.ResponseWriter) Context {
 	}
 }
 
+// NewContext 创建不经过路由的Context，用于直接调用处理函数，如测试
+func (s *Server) NewContext(r *http.Request, w http.ResponseWriter) Context {
+	return s.newContext(r, w)
+}
+
 // Router returns the default router.
 func (s *Server) Router() *Router {
 	return s.router
@@ -121,8 +139,8 @@ func (s *Server) DefaultHTTPErrorHandler(err error, c Context) {
 
 	// Issue #1426
 	code := he.Code
-	message := he.Message
-	if m, ok := he.Message.(string); ok {
+	message := s.message(he, c)
+	if m, ok := message.(string); ok {
 		if s.Debug {
 			message = Map{"code": code, "message": m, "error": err.Error()}
 		} else {
@@ -143,6 +161,17 @@ func (s *Server) DefaultHTTPErrorHandler(err error, c Context) {
 	}
 }
 
+// message 返回错误消息，有消息key时按请求语言翻译
+func (s *Server) message(he *HTTPError, c Context) any {
+	if he.Key == "" || s.Translator == nil || c.Request() == nil {
+		return he.Message
+	}
+	if text, ok := s.Translator(c.Request(), he.Key, he.Params); ok {
+		return text
+	}
+	return he.Message
+}
+
 // DefaultHTTPOKHandler is the default HTTP ok handler. It sends a JSON response
 // with status code.
 func (s *Server) DefaultHTTPOKHandler(data any, c Context) error {
@@ -173,11 +202,18 @@ func (s *Server) Use(middleware ...MiddlewareFunc) {
 
 // Add registers a new route for an HTTP method and path with matching handler
 // in the router with optional route-level middleware.
-func (s *Server) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+func (s *Server) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) Routes {
 	s.router.Add(method, path, func(c Context) error {
 		h := applyMiddleware(handler, middleware...)
 		return h(c)
 	})
+	// 与 Router.Add 一致，路径以/开头
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+	r := &RouteInfo{Method: method, Path: path}
+	s.routes[pair{Method: method, Path: path}] = r
+	return Routes{r}
 }
 
 // Group creates a new router group with prefix and optional group-level middleware.
@@ -217,6 +253,11 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		// premiddleware 在路由查找之前加载
 		// 可以在premiddleware中处理url路径等参数以改变路由查找的行为
 		s.router.Find(r.Method, r.URL.EscapedPath(), ctx.c())
+		route := s.routes[pair{Method: r.Method, Path: ctx.c().path}]
+		if route != nil && route.resolve != nil {
+			route = route.resolve(ctx)
+		}
+		ctx.c().route = route
 		h := c.Handler()
 		h = applyMiddleware(h, s.middleware...)
 		ctx = c
@@ -0,0 +1,305 @@
+package server
+
+// API版本路由，同一接口的多个版本共用路由文件，新版本只需注册有变化的接口
+//
+//	api := s.Group("/api").Versions(server.VersionConfig{
+//		Versions: []server.APIVersion{
+//			{Name: "v1", Deprecated: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Sunset: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)},
+//			{Name: "v2"},
+//		},
+//		MediaType: "vnd.x",
+//	})
+//	v1 := api.Version("v1")
+//	v1.Get("users", server.Controller(controller.UserController{}, "list"))
+//	v1.Get("users/:id", server.Controller(controller.UserController{}, "info"))
+//	// v2 只覆盖 users，users/:id 沿用 v1
+//	api.Version("v2").Get("users", server.Controller(controller.UserV2Controller{}, "list"))
+//
+// 以下请求都由 v2 处理：
+//
+//	GET /api/v2/users/1
+//	GET /api/users/1                                    （未指定版本时使用默认版本）
+//	GET /api/users/1 X-API-Version: 2
+//	GET /api/users/1 Accept: application/vnd.x.v2+json
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var ErrUnsupportedAPIVersion = NewHTTPError(http.StatusBadRequest, "Unsupported API Version")
+
+// APIVersion API版本
+type APIVersion struct {
+	// Name 版本名，同时作为路径前缀，如 v1
+	Name string
+	// Deprecated 废弃时间，不为零时响应 Deprecation 头
+	Deprecated time.Time
+	// Sunset 下线时间，不为零时响应 Sunset 头
+	Sunset time.Time
+	// Link 迁移说明文档地址，以 Link: <url>; rel="deprecation" 响应
+	Link string
+}
+
+// VersionConfig API版本配置
+type VersionConfig struct {
+	// Versions 所有版本，按从旧到新的顺序排列
+	Versions []APIVersion
+	// Default 请求没有指定版本时使用的版本，默认为最新版本
+	Default string
+	// Header 指定版本的请求头，默认 X-API-Version，值为版本名或版本号，如 v2 或 2
+	Header string
+	// MediaType Accept 中的厂商媒体类型，如 vnd.x 时可以通过 Accept: application/vnd.x.v2+json 指定版本
+	MediaType string
+}
+
+// Versions 版本路由，由 Server.Versions 或 Group.Versions 创建
+type Versions struct {
+	conf   VersionConfig
+	def    int
+	add    func(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) Routes
+	routes map[pair]*versionRoute
+}
+
+// versionRoute 同一个路由在各版本的处理函数和元数据，没有覆盖的版本为nil
+type versionRoute struct {
+	handlers []HandlerFunc
+	metas    []Meta
+	// routes 各版本路径上注册的路由，下标与版本对应，最后一个为不带版本前缀的路径
+	routes []Routes
+	// base 上级路由组的元数据，各版本的元数据在此基础上合并
+	base Meta
+}
+
+// Versions 创建版本路由，配置错误时panic
+func (cm *common) Versions(conf VersionConfig) *Versions {
+	if len(conf.Versions) == 0 {
+		panic("versions: no version configured")
+	}
+	if conf.Header == "" {
+		conf.Header = HeaderXAPIVersion
+	}
+	vs := &Versions{
+		conf:   conf,
+		def:    len(conf.Versions) - 1,
+		add:    cm.add,
+		routes: make(map[pair]*versionRoute),
+	}
+	for i, v := range conf.Versions {
+		if v.Name == "" || strings.Contains(v.Name, "/") || vs.index(v.Name) != i {
+			panic("versions: invalid version name " + strconv.Quote(v.Name))
+		}
+	}
+	if conf.Default != "" {
+		if vs.def = vs.index(conf.Default); vs.def < 0 {
+			panic("versions: default version " + conf.Default + " not exists")
+		}
+	}
+	return vs
+}
+
+// Version 获取版本的路由组，版本不存在时panic
+func (vs *Versions) Version(name string, middleware ...MiddlewareFunc) *VersionGroup {
+	i := vs.index(name)
+	if i < 0 {
+		panic("versions: version " + name + " not exists")
+	}
+	g := &VersionGroup{versions: vs, version: i}
+	g.common.add = g.Add
+	g.Use(middleware...)
+	return g
+}
+
+// index 按版本名查找版本，名称不匹配时按版本号匹配，如 2 匹配 v2
+func (vs *Versions) index(name string) int {
+	i := slices.IndexFunc(vs.conf.Versions, func(v APIVersion) bool { return v.Name == name })
+	if i < 0 && name != "" && name[0] >= '0' && name[0] <= '9' {
+		i = slices.IndexFunc(vs.conf.Versions, func(v APIVersion) bool {
+			return strings.TrimPrefix(strings.ToLower(v.Name), "v") == name
+		})
+	}
+	return i
+}
+
+// register 记录版本的处理函数和元数据，路由第一次注册时在所有版本的路径前缀及无前缀的路径上注册
+// 返回该版本路径上的路由，设置的元数据只属于该版本，没有覆盖该路由的更新版本沿用该版本的元数据
+func (vs *Versions) register(method, path string, version int, h HandlerFunc, meta Meta) Routes {
+	key := pair{Method: method, Path: path}
+	r, ok := vs.routes[key]
+	if !ok {
+		n := len(vs.conf.Versions)
+		r = &versionRoute{handlers: make([]HandlerFunc, n), metas: make([]Meta, n)}
+		vs.routes[key] = r
+		for i, v := range vs.conf.Versions {
+			r.routes = append(r.routes, vs.add(method, concatPath(v.Name, path), vs.dispatch(r, i)))
+		}
+		unversioned := vs.add(method, path, vs.dispatch(r, -1))
+		for _, info := range unversioned {
+			info.resolve = func(c Context) *RouteInfo {
+				i, err := vs.version(c)
+				if err != nil || len(r.routes[i]) == 0 {
+					return info
+				}
+				return r.routes[i][0]
+			}
+		}
+		r.routes = append(r.routes, unversioned)
+		if len(unversioned) > 0 {
+			r.base = maps.Clone(unversioned[0].Meta)
+		}
+	}
+	r.handlers[version] = h
+	r.metas[version] = maps.Clone(r.base)
+	if r.metas[version] == nil {
+		r.metas[version] = make(Meta, len(meta))
+	}
+	maps.Copy(r.metas[version], meta)
+	r.link(vs.def)
+	return r.routes[version]
+}
+
+// link 将各版本路径上路由的元数据指向实际处理该版本的注册的元数据，不带版本前缀的路径使用默认版本
+func (r *versionRoute) link(def int) {
+	var meta Meta
+	effective := make([]Meta, len(r.metas))
+	for i := range r.metas {
+		if r.metas[i] != nil {
+			meta = r.metas[i]
+		}
+		effective[i] = meta
+		for _, info := range r.routes[i] {
+			info.Meta = meta
+		}
+	}
+	for _, info := range r.routes[len(r.metas)] {
+		info.Meta = effective[def]
+	}
+}
+
+// dispatch 按版本选择处理函数，版本没有覆盖该路由时使用更早版本的处理函数
+// version 为 -1 时从请求头协商版本
+func (vs *Versions) dispatch(r *versionRoute, version int) HandlerFunc {
+	return func(c Context) error {
+		i := version
+		if i < 0 {
+			var err error
+			if i, err = vs.negotiate(c); err != nil {
+				return err
+			}
+		}
+		for j := i; j >= 0; j-- {
+			if h := r.handlers[j]; h != nil {
+				c.c().apiVersion = vs.conf.Versions[i].Name
+				vs.writeHeaders(c, vs.conf.Versions[i])
+				return h(c)
+			}
+		}
+		return ErrNotFound
+	}
+}
+
+// negotiate 协商版本，并设置 Vary 响应头
+func (vs *Versions) negotiate(c Context) (int, error) {
+	header := c.ResponseWriter().Header()
+	header.Add(HeaderVary, vs.conf.Header)
+	if vs.conf.MediaType != "" {
+		header.Add(HeaderVary, HeaderAccept)
+	}
+	return vs.version(c)
+}
+
+// version 依次从版本请求头和 Accept 中获取版本，都没有指定时使用默认版本
+func (vs *Versions) version(c Context) (int, error) {
+	if name := strings.TrimSpace(c.Request().Header.Get(vs.conf.Header)); name != "" {
+		if i := vs.index(name); i >= 0 {
+			return i, nil
+		}
+		return 0, ErrUnsupportedAPIVersion
+	}
+	if vs.conf.MediaType != "" {
+		if name, ok := vs.acceptVersion(c.Request().Header.Get(HeaderAccept)); ok {
+			if i := vs.index(name); i >= 0 {
+				return i, nil
+			}
+			return 0, ErrUnsupportedAPIVersion
+		}
+	}
+	return vs.def, nil
+}
+
+// acceptVersion 从 Accept 中解析厂商媒体类型的版本，如 application/vnd.x.v2+json 的版本为 v2
+func (vs *Versions) acceptVersion(accept string) (string, bool) {
+	prefix := "application/" + vs.conf.MediaType + "."
+	for _, mt := range strings.Split(accept, ",") {
+		mt, _, _ = strings.Cut(mt, ";")
+		mt = strings.TrimSpace(mt)
+		if !strings.HasPrefix(mt, prefix) {
+			continue
+		}
+		name, _, _ := strings.Cut(mt[len(prefix):], "+")
+		return name, true
+	}
+	return "", false
+}
+
+// writeHeaders 废弃的版本响应 Deprecation（RFC 9745）和 Sunset（RFC 8594）头
+func (vs *Versions) writeHeaders(c Context, v APIVersion) {
+	header := c.ResponseWriter().Header()
+	if !v.Deprecated.IsZero() {
+		header.Set(HeaderDeprecation, "@"+strconv.FormatInt(v.Deprecated.Unix(), 10))
+		if v.Link != "" {
+			header.Add(HeaderLink, "<"+v.Link+`>; rel="deprecation"`)
+		}
+	}
+	if !v.Sunset.IsZero() {
+		header.Set(HeaderSunset, v.Sunset.UTC().Format(http.TimeFormat))
+	}
+}
+
+// VersionGroup 某个版本的路由组，用法与 Group 相同
+type VersionGroup struct {
+	common
+	versions   *Versions
+	version    int
+	prefix     string
+	middleware []MiddlewareFunc
+	meta       Meta
+}
+
+// Use 添加该版本路由组的中间件
+func (g *VersionGroup) Use(middleware ...MiddlewareFunc) {
+	g.middleware = append(g.middleware, middleware...)
+}
+
+// Add 注册该版本的路由
+func (g *VersionGroup) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) Routes {
+	m := make([]MiddlewareFunc, 0, len(g.middleware)+len(middleware))
+	m = append(m, g.middleware...)
+	m = append(m, middleware...)
+	return g.versions.register(method, concatPath(g.prefix, path), g.version, applyMiddleware(handler, m...), g.meta)
+}
+
+// Meta 设置该版本路由组的元数据，见 Group.Meta
+func (g *VersionGroup) Meta(meta Meta) *VersionGroup {
+	if g.meta == nil {
+		g.meta = make(Meta, len(meta))
+	}
+	maps.Copy(g.meta, meta)
+	return g
+}
+
+// Group 创建该版本下的子路由组
+func (g *VersionGroup) Group(prefix string, middleware ...MiddlewareFunc) *VersionGroup {
+	sub := &VersionGroup{versions: g.versions, version: g.version, prefix: concatPath(g.prefix, prefix)}
+	sub.common.add = sub.Add
+	sub.Use(g.middleware...)
+	sub.Use(middleware...)
+	if g.meta != nil {
+		sub.Meta(g.meta)
+	}
+	return sub
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestVersions(t *testing.T) {
+	deprecated := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	s := New()
+	api := s.Group("/api").Versions(VersionConfig{
+		Versions: []APIVersion{
+			{Name: "v1", Deprecated: deprecated, Sunset: sunset, Link: "https://example.com/migrate"},
+			{Name: "v2"},
+			{Name: "v3"},
+		},
+		Default:   "v2",
+		MediaType: "vnd.x",
+	})
+	handler := func(name string) HandlerFunc {
+		return func(c Context) error {
+			route := c.GetRoutePath()
+			return c.Blob(http.StatusOK, MIMETextPlain, []byte(name+" "+c.APIVersion()+" "+route))
+		}
+	}
+	v1 := api.Version("v1")
+	v1.Get("users", handler("users@v1"))
+	v1.Group("users").Get(":id", handler("user@v1"))
+	api.Version("v2").Get("users", handler("users@v2"))
+	api.Version("v3").Get("orders", handler("orders@v3"))
+
+	tests := []struct {
+		path   string
+		header map[string]string
+		code   int
+		want   string
+	}{
+		{"/api/v1/users", nil, http.StatusOK, "users@v1 v1 /api/v1/users"},
+		{"/api/v2/users", nil, http.StatusOK, "users@v2 v2 /api/v2/users"},
+		// v3 没有覆盖 users，使用 v2
+		{"/api/v3/users", nil, http.StatusOK, "users@v2 v3 /api/v3/users"},
+		{"/api/v3/users/1", nil, http.StatusOK, "user@v1 v3 /api/v3/users/:id"},
+		{"/api/v2/orders", nil, http.StatusNotFound, ""},
+		{"/api/users", nil, http.StatusOK, "users@v2 v2 /api/users"},
+		{"/api/users", map[string]string{HeaderXAPIVersion: "1"}, http.StatusOK, "users@v1 v1 /api/users"},
+		{"/api/users", map[string]string{HeaderXAPIVersion: "v3"}, http.StatusOK, "users@v2 v3 /api/users"},
+		{"/api/users", map[string]string{HeaderXAPIVersion: "v9"}, http.StatusBadRequest, ""},
+		{"/api/users", map[string]string{HeaderAccept: "text/html, application/vnd.x.v1+json;q=0.9"}, http.StatusOK, "users@v1 v1 /api/users"},
+		{"/api/users", map[string]string{HeaderAccept: "application/vnd.x.v4+json"}, http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if rec.Code != tt.code {
+			t.Errorf("%s %v: status = %d, want %d", tt.path, tt.header, rec.Code, tt.code)
+			continue
+		}
+		if tt.want != "" && rec.Body.String() != tt.want {
+			t.Errorf("%s %v: body = %q, want %q", tt.path, tt.header, rec.Body.String(), tt.want)
+		}
+		deprecatedVersion := tt.code == http.StatusOK && (tt.path == "/api/v1/users" || tt.header[HeaderXAPIVersion] == "1" || tt.header[HeaderAccept] != "")
+		if got := rec.Header().Get(HeaderDeprecation) != ""; got != deprecatedVersion {
+			t.Errorf("%s %v: deprecation = %q", tt.path, tt.header, rec.Header().Get(HeaderDeprecation))
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/users", nil))
+	if got := rec.Header().Get(HeaderDeprecation); got != "@1735689600" {
+		t.Errorf("deprecation = %s", got)
+	}
+	if got := rec.Header().Get(HeaderSunset); got != "Tue, 01 Jul 2025 00:00:00 GMT" {
+		t.Errorf("sunset = %s", got)
+	}
+	if got := rec.Header().Get(HeaderLink); got != `<https://example.com/migrate>; rel="deprecation"` {
+		t.Errorf("link = %s", got)
+	}
+}
+
+func TestVersionsMeta(t *testing.T) {
+	s := New()
+	s.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) error {
+			meta := c.RouteMeta()
+			c.ResponseWriter().Header().Set("X-Meta", meta.String("perm")+","+meta.String("owner")+","+meta.String("team"))
+			return next(c)
+		}
+	})
+	api := s.Group("/api").Meta(Meta{"team": "api"}).Versions(VersionConfig{
+		Versions: []APIVersion{{Name: "v1"}, {Name: "v2"}, {Name: "v3"}},
+		Default:  "v1",
+	})
+	ok := func(c Context) error { return c.NoContent(http.StatusOK) }
+	api.Version("v1").Meta(Meta{"owner": "teamA"}).Get("users", ok).With(Meta{"perm": "public"})
+	api.Version("v2").Get("users", ok).With(Meta{"perm": "admin", "team": "core"})
+
+	tests := []struct {
+		path    string
+		version string
+		want    string
+	}{
+		{"/api/v1/users", "", "public,teamA,api"},
+		{"/api/v2/users", "", "admin,,core"},
+		// v3 没有覆盖 users，使用 v2 的元数据
+		{"/api/v3/users", "", "admin,,core"},
+		{"/api/users", "", "public,teamA,api"},
+		{"/api/users", "2", "admin,,core"},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+		if tt.version != "" {
+			req.Header.Set(HeaderXAPIVersion, tt.version)
+		}
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, req)
+		if got := rec.Header().Get("X-Meta"); rec.Code != http.StatusOK || got != tt.want {
+			t.Errorf("%s %s: status = %d, meta = %q, want %q", tt.path, tt.version, rec.Code, got, tt.want)
+		}
+	}
+
+	for _, r := range s.Routes() {
+		if r.Path == "/api/v1/users" && r.Meta.String("perm") != "public" {
+			t.Errorf("routes: %s meta = %v", r.Path, r.Meta)
+		}
+		if r.Path == "/api/v2/users" && r.Meta.String("perm") != "admin" {
+			t.Errorf("routes: %s meta = %v", r.Path, r.Meta)
+		}
+	}
+}
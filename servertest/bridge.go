@@ -0,0 +1,172 @@
+package servertest
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lazygo/lazygo/server"
+)
+
+var _ server.SendReceiveCloser = (*Bridge)(nil)
+
+// Timeout Bridge 等待消息的超时时间
+var Timeout = time.Second
+
+// Bridge 模拟WebSocket/CALL客户端的连接，用于测试Event处理函数
+//
+//	b := servertest.Serve(t, s.Event(server.MethodWebSocket, "uid:1"), 1)
+//	resp := b.Request(t, "/api/info", nil)
+type Bridge struct {
+	in     chan *server.EventData
+	out    chan *server.EventData
+	ready  chan struct{}
+	closed chan struct{}
+	once   sync.Once
+	rid    atomic.Uint64
+
+	mu      sync.Mutex
+	pending map[uint64]chan *server.EventData
+}
+
+// NewBridge 创建模拟连接
+func NewBridge() *Bridge {
+	b := &Bridge{
+		in:      make(chan *server.EventData),
+		out:     make(chan *server.EventData, 64),
+		ready:   make(chan struct{}),
+		closed:  make(chan struct{}),
+		pending: make(map[uint64]chan *server.EventData),
+	}
+	// 避免与服务端发起请求的rid冲突
+	b.rid.Store(1 << 32)
+	return b
+}
+
+// Serve 在后台调用 Event.Serve 建立模拟连接，连接建立后返回，测试结束时断开
+func Serve(t testing.TB, e *server.Event, cid uint64) *Bridge {
+	t.Helper()
+	b := NewBridge()
+	ctx, cancel := stdContext.WithCancel(stdContext.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- e.Serve(ctx, cid, b)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	select {
+	case <-b.ready:
+	case err := <-done:
+		t.Fatalf("serve event fail: %v", err)
+	case <-time.After(Timeout):
+		t.Fatal("serve event timeout")
+	}
+	return b
+}
+
+// Receive 由Event调用，读取客户端发送的消息
+func (b *Bridge) Receive(ctx stdContext.Context) (*server.EventData, error) {
+	b.once.Do(func() { close(b.ready) })
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-b.closed:
+		return nil, server.ErrChannelClosed
+	case data := <-b.in:
+		return data, nil
+	}
+}
+
+// Send 由Event调用，向客户端发送消息，请求的响应交给等待中的 Request
+func (b *Bridge) Send(data *server.EventData) error {
+	if data.RID > 0 {
+		b.mu.Lock()
+		ch, ok := b.pending[data.RID]
+		delete(b.pending, data.RID)
+		b.mu.Unlock()
+		if ok {
+			ch <- data
+			return nil
+		}
+	}
+	select {
+	case b.out <- data:
+		return nil
+	case <-b.closed:
+		return server.ErrChannelClosed
+	}
+}
+
+// Close 由Event在连接结束时调用
+func (b *Bridge) Close() error {
+	select {
+	case <-b.closed:
+	default:
+		close(b.closed)
+	}
+	return nil
+}
+
+// Push 模拟客户端发送消息
+func (b *Bridge) Push(t testing.TB, data *server.EventData) {
+	t.Helper()
+	if data.Header == nil {
+		data.Header = http.Header{}
+	}
+	select {
+	case b.in <- data:
+	case <-b.closed:
+		t.Fatal("bridge closed")
+	case <-time.After(Timeout):
+		t.Fatal("push event data timeout")
+	}
+}
+
+// Request 模拟客户端发送请求并等待响应，body 为 []byte 时按原样发送，其他类型编码为json
+func (b *Bridge) Request(t testing.TB, uri string, body any) *server.EventData {
+	t.Helper()
+	data := &server.EventData{RID: b.rid.Add(1), URI: uri, Header: http.Header{}}
+	switch v := body.(type) {
+	case nil:
+	case []byte:
+		data.Body = v
+	default:
+		raw, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data.Body = raw
+		data.Header.Set(server.HeaderContentType, server.MIMEApplicationJSON)
+	}
+	ch := make(chan *server.EventData, 1)
+	b.mu.Lock()
+	b.pending[data.RID] = ch
+	b.mu.Unlock()
+	b.Push(t, data)
+
+	select {
+	case resp := <-ch:
+		return resp
+	case <-time.After(Timeout):
+		t.Fatalf("wait response of %s timeout", uri)
+		return nil
+	}
+}
+
+// Next 获取服务端推送的下一条消息，如广播或服务端发起的请求
+func (b *Bridge) Next(t testing.TB) *server.EventData {
+	t.Helper()
+	select {
+	case data := <-b.out:
+		return data
+	case <-time.After(Timeout):
+		t.Fatal("wait event data timeout")
+		return nil
+	}
+}
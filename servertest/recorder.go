@@ -0,0 +1,74 @@
+package servertest
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/lazygo/lazygo/server"
+)
+
+// Recorder 记录响应，并提供json和错误响应的解析
+type Recorder struct {
+	*httptest.ResponseRecorder
+}
+
+// NewRecorder 创建响应记录器
+func NewRecorder() *Recorder {
+	return &Recorder{ResponseRecorder: httptest.NewRecorder()}
+}
+
+// ContentType 响应的 Content-Type，不包含参数
+func (r *Recorder) ContentType() string {
+	return trimContentType(r.Header().Get(server.HeaderContentType))
+}
+
+// JSON 将响应体解析到v，Content-Type 须为 application/json 或以 +json 结尾，如 application/problem+json
+func (r *Recorder) JSON(v any) error {
+	ct := r.Header().Get(server.HeaderContentType)
+	mt, _, err := mime.ParseMediaType(ct)
+	if err != nil || (mt != server.MIMEApplicationJSON && !strings.HasSuffix(mt, "+json")) {
+		return fmt.Errorf("unexpected content type %q", ct)
+	}
+	return json.Unmarshal(r.Body.Bytes(), v)
+}
+
+// Data 将 HTTPOKHandler 默认响应 {"errno": 0, "data": ...} 中的data解析到v
+func (r *Recorder) Data(v any) error {
+	var resp struct {
+		Errno int             `json:"errno"`
+		Data  json.RawMessage `json:"data"`
+	}
+	if err := r.JSON(&resp); err != nil {
+		return err
+	}
+	if resp.Errno != 0 {
+		return fmt.Errorf("errno %d: %s", resp.Errno, r.Body.String())
+	}
+	return json.Unmarshal(resp.Data, v)
+}
+
+// HTTPError 解析 HTTPErrorHandler 默认的错误响应 {"code": ..., "message": ...}，
+// 响应状态码小于400时返回nil
+func (r *Recorder) HTTPError() *server.HTTPError {
+	if r.Code < 400 {
+		return nil
+	}
+	he := &server.HTTPError{Code: r.Code, Errno: r.Code}
+	var resp struct {
+		Code    int `json:"code"`
+		Errno   int `json:"errno"`
+		Message any `json:"message"`
+	}
+	if err := json.Unmarshal(r.Body.Bytes(), &resp); err != nil {
+		he.Message = r.Body.String()
+		return he
+	}
+	if resp.Errno != 0 {
+		he.Errno = resp.Errno
+	}
+	he.Message = resp.Message
+	return he
+}
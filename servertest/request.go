@@ -0,0 +1,230 @@
+package servertest
+
+import (
+	"bytes"
+	stdContext "context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+
+	"github.com/lazygo/lazygo/server"
+)
+
+type formFile struct {
+	field    string
+	filename string
+	content  []byte
+}
+
+// RequestBuilder 测试请求构造器
+//
+//	rec := servertest.NewRequest(http.MethodPost, "/api/user").
+//		Header("Authorization", "Bearer xxx").
+//		JSON(map[string]any{"name": "test"}).
+//		Serve(s)
+type RequestBuilder struct {
+	ctx         stdContext.Context
+	method      string
+	target      string
+	header      http.Header
+	query       url.Values
+	form        url.Values
+	files       []formFile
+	cookies     []*http.Cookie
+	contentType string
+	body        []byte
+	remoteAddr  string
+	routePath   string
+	pnames      []string
+	pvalues     []string
+	err         error
+}
+
+// NewRequest 创建请求构造器，target 为请求路径，可以包含query参数
+func NewRequest(method, target string) *RequestBuilder {
+	return &RequestBuilder{
+		ctx:    stdContext.Background(),
+		method: method,
+		target: target,
+		header: http.Header{},
+		query:  url.Values{},
+		form:   url.Values{},
+	}
+}
+
+// WithContext 设置请求的ctx
+func (b *RequestBuilder) WithContext(ctx stdContext.Context) *RequestBuilder {
+	b.ctx = ctx
+	return b
+}
+
+// Header 添加请求头
+func (b *RequestBuilder) Header(key, value string) *RequestBuilder {
+	b.header.Add(key, value)
+	return b
+}
+
+// Query 添加query参数
+func (b *RequestBuilder) Query(key, value string) *RequestBuilder {
+	b.query.Add(key, value)
+	return b
+}
+
+// Cookie 添加cookie
+func (b *RequestBuilder) Cookie(name, value string) *RequestBuilder {
+	b.cookies = append(b.cookies, &http.Cookie{Name: name, Value: value})
+	return b
+}
+
+// RemoteAddr 设置客户端地址，如 "10.0.0.1:1234"
+func (b *RequestBuilder) RemoteAddr(addr string) *RequestBuilder {
+	b.remoteAddr = addr
+	return b
+}
+
+// JSON 使用json编码v作为请求体
+func (b *RequestBuilder) JSON(v any) *RequestBuilder {
+	body, err := json.Marshal(v)
+	if err != nil {
+		b.err = err
+		return b
+	}
+	return b.Body(server.MIMEApplicationJSON, body)
+}
+
+// Body 设置原始请求体
+func (b *RequestBuilder) Body(contentType string, body []byte) *RequestBuilder {
+	b.contentType = contentType
+	b.body = body
+	return b
+}
+
+// Form 添加表单字段，添加了文件时以multipart发送
+func (b *RequestBuilder) Form(key, value string) *RequestBuilder {
+	b.form.Add(key, value)
+	return b
+}
+
+// File 添加上传文件，请求以multipart发送
+func (b *RequestBuilder) File(field, filename string, content []byte) *RequestBuilder {
+	b.files = append(b.files, formFile{field: field, filename: filename, content: content})
+	return b
+}
+
+// Param 设置路由参数，用于 Context 和 Handle 不经过路由直接调用处理函数
+func (b *RequestBuilder) Param(name, value string) *RequestBuilder {
+	b.pnames = append(b.pnames, name)
+	b.pvalues = append(b.pvalues, value)
+	return b
+}
+
+// RoutePath 设置路由路径，用于 Context 和 Handle，如 /api/user/:id
+// Controller 未指定方法名时根据路由路径的最后一段查找方法
+func (b *RequestBuilder) RoutePath(path string) *RequestBuilder {
+	b.routePath = path
+	return b
+}
+
+// Request 生成 *http.Request，构造过程中的错误通过panic抛出
+func (b *RequestBuilder) Request() *http.Request {
+	if b.err != nil {
+		panic(b.err)
+	}
+	contentType, body := b.contentType, b.body
+	if len(b.files) > 0 {
+		contentType, body = b.multipart()
+	} else if len(b.form) > 0 {
+		contentType, body = server.MIMEApplicationForm, []byte(b.form.Encode())
+	}
+
+	r := httptest.NewRequestWithContext(b.ctx, b.method, b.target, bytes.NewReader(body))
+	if len(b.query) > 0 {
+		query := r.URL.Query()
+		for k, v := range b.query {
+			query[k] = append(query[k], v...)
+		}
+		r.URL.RawQuery = query.Encode()
+		r.RequestURI = r.URL.RequestURI()
+	}
+	for k, v := range b.header {
+		r.Header[k] = v
+	}
+	if contentType != "" {
+		r.Header.Set(server.HeaderContentType, contentType)
+	}
+	for _, c := range b.cookies {
+		r.AddCookie(c)
+	}
+	if b.remoteAddr != "" {
+		r.RemoteAddr = b.remoteAddr
+	}
+	return r
+}
+
+func (b *RequestBuilder) multipart() (string, []byte) {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for k, values := range b.form {
+		for _, v := range values {
+			_ = w.WriteField(k, v)
+		}
+	}
+	for _, f := range b.files {
+		part, err := w.CreateFormFile(f.field, f.filename)
+		if err != nil {
+			panic(err)
+		}
+		_, _ = io.Copy(part, bytes.NewReader(f.content))
+	}
+	_ = w.Close()
+	return w.FormDataContentType(), buf.Bytes()
+}
+
+// Serve 通过 Server.ServeHTTP 发送请求，经过路由和完整的中间件链
+func (b *RequestBuilder) Serve(h http.Handler) *Recorder {
+	rec := NewRecorder()
+	h.ServeHTTP(rec, b.Request())
+	return rec
+}
+
+// routeSetter server.NewContext 创建的Context实现的方法，不在 server.Context 接口中
+type routeSetter interface {
+	SetRoutePath(path string)
+	SetParams(names, values []string)
+}
+
+// Context 创建不经过路由的Context，并设置路由路径和参数，返回的 Recorder 记录响应
+func (b *RequestBuilder) Context(s *server.Server) (server.Context, *Recorder) {
+	rec := NewRecorder()
+	ctx := s.NewContext(b.Request(), rec)
+	if rs, ok := ctx.(routeSetter); ok {
+		rs.SetRoutePath(b.routePath)
+		if len(b.pnames) > 0 {
+			rs.SetParams(b.pnames, b.pvalues)
+		}
+	}
+	return ctx, rec
+}
+
+// Handle 不经过路由直接调用处理函数，如 server.Controller(UserController{}, "Info")，
+// 处理函数返回的错误交给 HTTPErrorHandler，与 ServeHTTP 一致
+func (b *RequestBuilder) Handle(s *server.Server, h server.HandlerFunc, m ...server.MiddlewareFunc) *Recorder {
+	ctx, rec := b.Context(s)
+	for i := len(m) - 1; i >= 0; i-- {
+		h = m[i](h)
+	}
+	if err := h(ctx); err != nil {
+		s.HTTPErrorHandler(err, ctx)
+	}
+	return rec
+}
+
+// trimContentType 去掉 Content-Type 中的参数，如 charset
+func trimContentType(contentType string) string {
+	contentType, _, _ = strings.Cut(contentType, ";")
+	return strings.TrimSpace(contentType)
+}
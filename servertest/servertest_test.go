@@ -0,0 +1,151 @@
+package servertest
+
+import (
+	stdContext "context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/lazygo/lazygo/server"
+)
+
+type testController struct {
+	Ctx server.Context
+}
+
+type testInfoRequest struct {
+	ID   int    `json:"id" bind:"param"`
+	Name string `json:"name" bind:"query"`
+}
+
+func (r *testInfoRequest) Verify() error {
+	if r.ID == 0 {
+		return server.ErrBadRequest
+	}
+	return nil
+}
+
+func (ctl *testController) Info(req *testInfoRequest) (any, error) {
+	return server.Map{"id": req.ID, "name": req.Name}, nil
+}
+
+func TestRequest(t *testing.T) {
+	s := server.New()
+	s.Post("/upload", func(c server.Context) error {
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return err
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		content, _ := io.ReadAll(f)
+		session, _ := c.Cookie("session")
+		return c.JSON(http.StatusOK, server.Map{
+			"title":   c.FormValue("title"),
+			"file":    string(content),
+			"session": session,
+			"token":   c.RequestHeader("X-Token"),
+			"page":    c.QueryParam("page"),
+		})
+	})
+
+	rec := NewRequest(http.MethodPost, "/upload").
+		Query("page", "2").
+		Header("X-Token", "abc").
+		Cookie("session", "s1").
+		Form("title", "hello").
+		File("file", "a.txt", []byte("content")).
+		Serve(s)
+	var body map[string]string
+	if err := rec.JSON(&body); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"title": "hello", "file": "content", "session": "s1", "token": "abc", "page": "2"}
+	for k, v := range want {
+		if body[k] != v {
+			t.Errorf("%s = %q, want %q", k, body[k], v)
+		}
+	}
+
+	rec = NewRequest(http.MethodGet, "/missing").Serve(s)
+	if he := rec.HTTPError(); he == nil || he.Code != http.StatusNotFound {
+		t.Errorf("HTTPError() = %v", he)
+	}
+
+	s.HTTPErrorHandler = s.ProblemHTTPErrorHandler
+	rec = NewRequest(http.MethodGet, "/missing").Serve(s)
+	var problem server.Problem
+	if err := rec.JSON(&problem); err != nil {
+		t.Fatal(err)
+	}
+	if problem.Status != http.StatusNotFound {
+		t.Errorf("problem = %+v", problem)
+	}
+
+	s.Get("/text", func(c server.Context) error {
+		return c.Blob(http.StatusOK, server.MIMETextPlain, []byte("{}"))
+	})
+	if err := NewRequest(http.MethodGet, "/text").Serve(s).JSON(&body); err == nil {
+		t.Error("JSON() expected error for text/plain")
+	}
+}
+
+func TestHandleController(t *testing.T) {
+	s := server.New()
+	h := server.Controller(testController{}, "Info")
+
+	rec := NewRequest(http.MethodGet, "/user/42?name=test").Param("id", "42").Handle(s, h)
+	var data struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := rec.Data(&data); err != nil {
+		t.Fatal(err)
+	}
+	if data.ID != 42 || data.Name != "test" {
+		t.Errorf("data = %+v", data)
+	}
+
+	rec = NewRequest(http.MethodGet, "/user/0").Param("id", "0").Handle(s, h)
+	if he := rec.HTTPError(); he == nil || he.Code != http.StatusBadRequest {
+		t.Errorf("HTTPError() = %v", he)
+	}
+}
+
+func TestBridge(t *testing.T) {
+	s := server.New()
+	s.WebSocket("/echo", func(c server.Context) error {
+		body, _ := io.ReadAll(c.Request().Body)
+		return c.Blob(http.StatusOK, server.MIMEApplicationJSON, body)
+	})
+	e := s.Event(server.MethodWebSocket, "/ws")
+	e.SetAuth(server.EventAuth{
+		Authorize: func(_ stdContext.Context, _ any, req *server.EventData) error {
+			if req.URI == "/deny" {
+				return errors.New("denied")
+			}
+			return nil
+		},
+	})
+	b := Serve(t, e, 1)
+
+	if resp := b.Request(t, "/echo", map[string]int{"a": 1}); string(resp.Body) != `{"a":1}` {
+		t.Errorf("echo = %s", resp.Body)
+	}
+	var deny server.HTTPError
+	if err := json.Unmarshal(b.Request(t, "/deny", nil).Body, &deny); err != nil || deny.Code != http.StatusForbidden {
+		t.Errorf("deny = %+v, %v", deny, err)
+	}
+
+	if err := e.Broadcast(t.Context(), &server.EventData{URI: "/notice"}); err != nil {
+		t.Fatal(err)
+	}
+	if data := b.Next(t); data.URI != "/notice" {
+		t.Errorf("next uri = %s", data.URI)
+	}
+}
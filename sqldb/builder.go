@@ -1,6 +1,7 @@
 package sqldb
 
 import (
+	"database/sql"
 	"fmt"
 	"slices"
 	"strconv"
@@ -12,6 +13,7 @@ type RBuilder interface {
 	QueryString() (string, []any, error)
 	Count() (int64, error)
 	Find(result any) (int, error)
+	Rows() (*sql.Rows, error)
 	First(result any) (int, error)
 	One(field string) (string, error)
 }
@@ -250,36 +252,50 @@ func (b *builder) Count() (int64, error) {
 // Find 查询并返回多条记录
 // field string 返回的字段 示例："*"
 func (b *builder) Find(result any) (int, error) {
-
+	// QueryString 会清空hook，需要先取出
+	before := b.before
 	queryString, args, err := b.QueryString()
 	if err != nil {
 		return 0, err
 	}
 
-	return b.tx.Before(b.before).Find(result, queryString, args...)
+	return b.tx.withBefore(before).Find(result, queryString, args...)
+}
+
+// Rows 查询并返回结果集，用于逐行读取大量数据，使用完毕后需要关闭，见 Scan
+func (b *builder) Rows() (*sql.Rows, error) {
+	before := b.before
+	queryString, args, err := b.QueryString()
+	if err != nil {
+		return nil, err
+	}
+
+	return b.tx.withBefore(before).Query(queryString, args...)
 }
 
 // First 查询并返回单条记录
 // field string 返回的字段 示例："*"
 func (b *builder) First(result any) (int, error) {
+	before := b.before
 	queryString, args, err := b.Limit(1).QueryString()
 	if err != nil {
 		return 0, err
 	}
 
-	return b.tx.Before(b.before).First(result, queryString, args...)
+	return b.tx.withBefore(before).First(result, queryString, args...)
 }
 
 // One 查询并返回单个字段
 // field string 返回的字段 示例："count(*) AS count"
 func (b *builder) One(field string) (string, error) {
+	before := b.before
 	queryString, args, err := b.Limit(1).Select(field).QueryString()
 	if err != nil {
 		return "", err
 	}
 
 	item := map[string]string{}
-	_, err = b.tx.Before(b.before).First(&item, queryString, args...)
+	_, err = b.tx.withBefore(before).First(&item, queryString, args...)
 	if err != nil {
 		return "", err
 	}
@@ -310,7 +326,7 @@ func (b *builder) Insert(set map[string]any) (int64, error) {
 	queryString := "INSERT INTO " + b.table.String() + " (" + strings.Join(fields, ", ") + ") VALUES (" + strings.Join(values, ", ") + ")"
 
 	// 执行插入语句
-	res, err := b.tx.Before(b.before).Exec(queryString, args...)
+	res, err := b.tx.withBefore(b.before).Exec(queryString, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -363,7 +379,7 @@ func (b *builder) Update(set map[string]any, limit ...int) (int64, error) {
 	}
 
 	// 执行更新语句
-	res, err := b.tx.Before(b.before).Exec(queryString, args...)
+	res, err := b.tx.withBefore(b.before).Exec(queryString, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -410,7 +426,7 @@ func (b *builder) UpdateRaw(set string, limit ...int) (int64, error) {
 	}
 
 	// 执行更新语句
-	res, err := b.tx.Before(b.before).Exec(queryString, args...)
+	res, err := b.tx.withBefore(b.before).Exec(queryString, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -469,7 +485,7 @@ func (b *builder) Increment(column string, amount int64, set ...map[string]any)
 
 	// 执行更新sql语句
 	args = append(valArgs, args...)
-	res, err := b.tx.Before(b.before).Exec(queryString, args...)
+	res, err := b.tx.withBefore(b.before).Exec(queryString, args...)
 	if err != nil {
 		return 0, err
 	}
@@ -517,7 +533,7 @@ func (b *builder) Delete(limit ...int) (int64, error) {
 	}
 
 	// 获取影响的行数
-	res, err := b.tx.Before(b.before).Exec(queryString, args...)
+	res, err := b.tx.withBefore(b.before).Exec(queryString, args...)
 	if err != nil {
 		return 0, err
 	}
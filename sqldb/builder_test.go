@@ -1,6 +1,7 @@
 package sqldb
 
 import (
+	"database/sql"
 	"fmt"
 	"sort"
 	"strings"
@@ -106,3 +107,47 @@ func TestBuildVal(t *testing.T) {
 	assert.Equal(strset, "`last_view_time` = 12345678, `last_view_user` = li, view_num=view_num+1", "错误")
 
 }
+
+func TestBeforeHook(t *testing.T) {
+	assert := testify.New(t)
+	db, err := sql.Open("sqlite3", ":memory:")
+	if !assert.NoError(err) {
+		return
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE orders (id INTEGER, uid TEXT);
+		INSERT INTO orders VALUES (1, 'a'), (2, 'b')`)
+	if !assert.NoError(err) {
+		return
+	}
+
+	var global, hooked []string
+	tx := &Tx{invoker: db, name: "test"}
+	tx.Before(func(query string, args ...any) func() {
+		global = append(global, query)
+		return nil
+	})
+
+	_, err = tx.Table("orders").BeforeHook(func(query string, args ...any) func() {
+		hooked = append(hooked, query)
+		return nil
+	}).Insert(map[string]any{"id": 3, "uid": "c"})
+	assert.NoError(err)
+
+	// 查询构建器的hook只用于本次查询，不替换Tx上的hook
+	_, err = tx.Table("orders").Insert(map[string]any{"id": 4, "uid": "d"})
+	assert.NoError(err)
+	assert.Len(hooked, 1)
+	assert.Len(global, 1)
+
+	// 查询语句同样使用hook
+	var list []map[string]string
+	_, err = tx.Table("orders").BeforeHook(func(query string, args ...any) func() {
+		hooked = append(hooked, query)
+		return nil
+	}).Where("uid", "a").Find(&list)
+	assert.NoError(err)
+	assert.Len(list, 1)
+	assert.Len(hooked, 2)
+	assert.Len(global, 1)
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"time"
 )
 
 type DB struct{ Tx }
@@ -38,10 +39,22 @@ func (d *Tx) Before(h func(string, ...any) func()) *Tx {
 	return d
 }
 
+// withBefore 返回使用指定hook的副本，不修改共享的Tx，用于查询构建器的单次查询
+func (d *Tx) withBefore(h func(string, ...any) func()) *Tx {
+	if h == nil {
+		return d
+	}
+	tx := *d
+	tx.before = h
+	return &tx
+}
+
 // Query 查询sql并返回结果集
 func (d *Tx) Query(sql string, args ...any) (*sql.Rows, error) {
 	after := d.before(sql, args...)
+	start := time.Now()
 	row, err := d.invoker.Query(sql, args...)
+	observeQuery(d.name, sql, start, err)
 	if after != nil {
 		after()
 	}
@@ -58,7 +71,9 @@ func (d *Tx) Query(sql string, args ...any) (*sql.Rows, error) {
 // Exec 执行sql
 func (d *Tx) Exec(sql string, args ...any) (sql.Result, error) {
 	after := d.before(sql, args...)
+	start := time.Now()
 	result, err := d.invoker.Exec(sql, args...)
+	observeQuery(d.name, sql, start, err)
 	if after != nil {
 		after()
 	}
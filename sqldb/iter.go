@@ -0,0 +1,79 @@
+package sqldb
+
+import (
+	"database/sql"
+	"iter"
+	"reflect"
+)
+
+// Scan 逐行解析结果集，T 为结构体或 map[string]string、map[string]any，遍历结束或中断时关闭结果集
+// 解析或读取失败时返回一次错误后结束
+func Scan[T any](rows *sql.Rows) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		defer rows.Close()
+		var zero T
+
+		columns, err := rows.Columns()
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+
+		rt := reflect.TypeFor[T]()
+		var next func() (T, error)
+		switch rt.Kind() {
+		case reflect.Struct:
+			rv := reflect.New(rt).Elem()
+			fieldPtr, err := getFieldPtr(columns, rv)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			next = func() (T, error) {
+				// 每行重置，避免上一行的值残留在未选择的字段中
+				rv.SetZero()
+				if err := rows.Scan(fieldPtr...); err != nil {
+					return zero, err
+				}
+				return rv.Interface().(T), nil
+			}
+		case reflect.Map:
+			if err := checkMap(rt); err != nil {
+				yield(zero, err)
+				return
+			}
+			fieldPtr, fieldArr, fieldToID := getResultPtr(columns)
+			next = func() (T, error) {
+				if err := rows.Scan(fieldPtr...); err != nil {
+					return zero, err
+				}
+				rv := reflect.MakeMapWithSize(rt, len(columns))
+				for k, v := range fieldToID {
+					val := reflect.ValueOf("")
+					if fieldArr[v] != nil {
+						val = reflect.ValueOf(string(fieldArr[v]))
+					}
+					rv.SetMapIndex(reflect.ValueOf(k), val)
+				}
+				return rv.Interface().(T), nil
+			}
+		default:
+			yield(zero, ErrInvalidResultPtr)
+			return
+		}
+
+		for rows.Next() {
+			row, err := next()
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if !yield(row, nil) {
+				return
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
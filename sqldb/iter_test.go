@@ -0,0 +1,58 @@
+package sqldb
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	testify "github.com/stretchr/testify/assert"
+)
+
+func TestScan(t *testing.T) {
+	assert := testify.New(t)
+	db, err := sql.Open("sqlite3", ":memory:")
+	if !assert.NoError(err) {
+		return
+	}
+	defer db.Close()
+	_, err = db.Exec(`CREATE TABLE orders (id INTEGER, uid TEXT, name TEXT NULL);
+		INSERT INTO orders VALUES (1, 'a', 'x'), (2, 'b', NULL), (3, 'c', 'z')`)
+	if !assert.NoError(err) {
+		return
+	}
+
+	rows, err := db.Query("SELECT id, uid, name FROM orders ORDER BY id")
+	if !assert.NoError(err) {
+		return
+	}
+	var list []Order
+	for row, err := range Scan[Order](rows) {
+		assert.NoError(err)
+		list = append(list, row)
+	}
+	assert.Equal([]Order{
+		{ID: 1, UID: "a", Name: sql.NullString{String: "x", Valid: true}},
+		{ID: 2, UID: "b"},
+		{ID: 3, UID: "c", Name: sql.NullString{String: "z", Valid: true}},
+	}, list)
+
+	// 中断遍历时关闭结果集
+	rows, err = db.Query("SELECT id, uid FROM orders ORDER BY id")
+	if !assert.NoError(err) {
+		return
+	}
+	for row, err := range Scan[map[string]string](rows) {
+		assert.NoError(err)
+		assert.Equal(map[string]string{"id": "1", "uid": "a"}, row)
+		break
+	}
+	assert.ErrorContains(rows.Scan(), "closed")
+
+	rows, err = db.Query("SELECT id FROM orders")
+	if !assert.NoError(err) {
+		return
+	}
+	for _, err := range Scan[int](rows) {
+		assert.ErrorIs(err, ErrInvalidResultPtr)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"io"
 	"sync"
 	"time"
+
+	"github.com/lazygo/lazygo/health"
 )
 
 type Manager struct {
@@ -33,6 +35,7 @@ func (m *Manager) init(conf []Config) error {
 			name:    item.name(),
 			before:  func(query string, args ...any) func() { return func() {} },
 		}})
+		health.Register("sqldb/"+item.name(), db.PingContext)
 	}
 	return nil
 }
@@ -46,6 +49,7 @@ func (m *Manager) closeAll() error {
 			return false
 		}
 		m.Delete(name)
+		health.Remove("sqldb/" + name.(string))
 		return true
 	})
 	return err
@@ -0,0 +1,63 @@
+package sqldb
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/lazygo/lazygo/metrics"
+)
+
+var queryDuration = metrics.NewHistogramVec("lazygo_sql_query_duration_seconds",
+	"SQL query latency in seconds.", nil, "db", "op", "status")
+
+func init() {
+	poolLabels := []string{"db"}
+	metrics.NewGaugeFunc("lazygo_sql_open_connections", "Number of established connections.", poolLabels,
+		poolStats(func(s sql.DBStats) float64 { return float64(s.OpenConnections) }))
+	metrics.NewGaugeFunc("lazygo_sql_in_use_connections", "Number of connections currently in use.", poolLabels,
+		poolStats(func(s sql.DBStats) float64 { return float64(s.InUse) }))
+	metrics.NewGaugeFunc("lazygo_sql_idle_connections", "Number of idle connections.", poolLabels,
+		poolStats(func(s sql.DBStats) float64 { return float64(s.Idle) }))
+	metrics.NewCounterFunc("lazygo_sql_wait_count_total", "Total number of connections waited for.", poolLabels,
+		poolStats(func(s sql.DBStats) float64 { return float64(s.WaitCount) }))
+	metrics.NewCounterFunc("lazygo_sql_wait_duration_seconds_total", "Total time blocked waiting for a new connection.", poolLabels,
+		poolStats(func(s sql.DBStats) float64 { return s.WaitDuration.Seconds() }))
+}
+
+// poolStats 采集时读取所有数据库连接池的状态
+func poolStats(value func(sql.DBStats) float64) func(observe func(float64, ...string)) {
+	return func(observe func(float64, ...string)) {
+		manager.Range(func(name, db any) bool {
+			if pool, ok := db.(*DB).invoker.(*sql.DB); ok {
+				observe(value(pool.Stats()), name.(string))
+			}
+			return true
+		})
+	}
+}
+
+// observeQuery 记录sql执行耗时，op为sql的第一个关键字，如select、insert
+func observeQuery(db, query string, start time.Time, err error) {
+	status := "ok"
+	if err != nil {
+		status = "error"
+	}
+	queryDuration.With(db, sqlOp(query), status).Observe(time.Since(start).Seconds())
+}
+
+func sqlOp(query string) string {
+	query = strings.TrimLeft(query, " \t\r\n(")
+	end := strings.IndexAny(query, " \t\r\n(")
+	if end < 0 {
+		end = len(query)
+	}
+	op := strings.ToLower(query[:end])
+	switch op {
+	case "select", "insert", "update", "delete", "replace", "with", "begin", "commit", "rollback":
+		return op
+	case "":
+		return "unknown"
+	}
+	return "other"
+}
@@ -2,6 +2,7 @@ package sqldb
 
 import (
 	"cmp"
+	"iter"
 	"strings"
 	"time"
 
@@ -139,6 +140,25 @@ func (mdl *TxModel[T]) Find(cond map[string]any, fields ...string) ([]T, int, er
 	return data, n, nil
 }
 
+// Iterate 逐行读取查询结果，不会一次加载所有数据，用于大量数据的导出
+//
+//	for row, err := range mdlUser.Iterate(cond) {
+//		if err != nil {
+//			return err
+//		}
+//	}
+func (mdl *TxModel[T]) Iterate(cond map[string]any, fields ...string) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		rows, err := mdl.QueryBuilder().Where(cond).Select(fields...).Rows()
+		if err != nil {
+			var zero T
+			yield(zero, err)
+			return
+		}
+		Scan[T](rows)(yield)
+	}
+}
+
 func (mdl *TxModel[T]) Paginator(cond map[string]any, req *PaginatorRequest, groupby []string, fields ...string) (*PaginatorResponse[T], int, error) {
 	var list []T
 	builder := mdl.QueryBuilder().Where(cond)
@@ -0,0 +1,17 @@
+package sqldb
+
+import (
+	"context"
+
+	"github.com/lazygo/lazygo/trace"
+)
+
+// TraceHook 返回为每条sql创建子span的hook，用于 BeforeHook
+// 如 db.Table("user").BeforeHook(sqldb.TraceHook(ctx, "main")).Where(...).Find(&list)
+func TraceHook(ctx context.Context, db string) func(string, ...any) func() {
+	return func(query string, args ...any) func() {
+		_, span := trace.StartChild(ctx, trace.KindClient, "SQL "+sqlOp(query))
+		span.SetAttr("db.name", db).SetAttr("db.statement", query)
+		return span.End
+	}
+}
@@ -0,0 +1,294 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const defaultOTLPTimeout = 10 * time.Second
+
+// stdoutExporter 每个span输出一行json
+type stdoutExporter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// NewStdoutExporter 创建按行输出json的导出器，w为nil时输出到标准输出
+func NewStdoutExporter(w io.Writer) Exporter {
+	if w == nil {
+		w = os.Stdout
+	}
+	return &stdoutExporter{w: w}
+}
+
+type stdoutSpan struct {
+	Service      string         `json:"service,omitempty"`
+	Name         string         `json:"name"`
+	Kind         string         `json:"kind"`
+	TraceID      string         `json:"trace_id"`
+	SpanID       string         `json:"span_id"`
+	ParentSpanID string         `json:"parent_span_id,omitempty"`
+	Start        time.Time      `json:"start"`
+	DurationMs   float64        `json:"duration_ms"`
+	Attributes   map[string]any `json:"attributes,omitempty"`
+	Status       string         `json:"status,omitempty"`
+	Message      string         `json:"message,omitempty"`
+}
+
+func (e *stdoutExporter) Export(_ context.Context, spans []*SpanData) error {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+	for _, s := range spans {
+		item := stdoutSpan{
+			Service:    s.ServiceName,
+			Name:       s.Name,
+			Kind:       kindName(s.Kind),
+			TraceID:    s.TraceID.String(),
+			SpanID:     s.SpanID.String(),
+			Start:      s.Start,
+			DurationMs: float64(s.End.Sub(s.Start).Microseconds()) / 1000,
+			Attributes: s.Attributes,
+			Message:    s.StatusMessage,
+		}
+		if s.ParentSpanID.IsValid() {
+			item.ParentSpanID = s.ParentSpanID.String()
+		}
+		switch s.Status {
+		case StatusOK:
+			item.Status = "ok"
+		case StatusError:
+			item.Status = "error"
+		}
+		if err := enc.Encode(item); err != nil {
+			return err
+		}
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, err := e.w.Write(buf.Bytes())
+	return err
+}
+
+func (e *stdoutExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func kindName(k SpanKind) string {
+	switch k {
+	case KindServer:
+		return "server"
+	case KindClient:
+		return "client"
+	}
+	return "internal"
+}
+
+type OTLPConfig struct {
+	// Endpoint 采集器地址，如 http://127.0.0.1:4318，请求发送到 {Endpoint}/v1/traces
+	Endpoint string
+	// Header 附加的请求头，如鉴权信息
+	Header http.Header
+	// Timeout 单次导出的超时时间，默认10秒
+	Timeout time.Duration
+	// Client 发送请求的http客户端，默认 http.DefaultClient
+	Client *http.Client
+}
+
+// otlpExporter 使用 OTLP/HTTP 协议以json编码导出
+type otlpExporter struct {
+	conf OTLPConfig
+	url  string
+}
+
+// NewOTLPExporter 创建 OTLP/HTTP json 导出器
+func NewOTLPExporter(conf OTLPConfig) Exporter {
+	if conf.Timeout <= 0 {
+		conf.Timeout = defaultOTLPTimeout
+	}
+	if conf.Client == nil {
+		conf.Client = http.DefaultClient
+	}
+	url := strings.TrimRight(conf.Endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	return &otlpExporter{conf: conf, url: url}
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	TraceState        string         `json:"traceState,omitempty"`
+	Name              string         `json:"name"`
+	Kind              SpanKind       `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+func (e *otlpExporter) Export(ctx context.Context, spans []*SpanData) error {
+	// 按服务名分组，每个服务一个 resource
+	groups := make(map[string][]otlpSpan)
+	var services []string
+	for _, s := range spans {
+		if _, ok := groups[s.ServiceName]; !ok {
+			services = append(services, s.ServiceName)
+		}
+		item := otlpSpan{
+			TraceID:           s.TraceID.String(),
+			SpanID:            s.SpanID.String(),
+			TraceState:        s.TraceState,
+			Name:              s.Name,
+			Kind:              s.Kind,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(s.Attributes),
+			Status:            otlpStatus{Code: s.Status, Message: s.StatusMessage},
+		}
+		if s.ParentSpanID.IsValid() {
+			item.ParentSpanID = s.ParentSpanID.String()
+		}
+		groups[s.ServiceName] = append(groups[s.ServiceName], item)
+	}
+	var req otlpRequest
+	for _, service := range services {
+		rs := otlpResourceSpans{}
+		if service != "" {
+			rs.Resource.Attributes = otlpAttributes(map[string]any{"service.name": service})
+		} else {
+			rs.Resource.Attributes = []otlpKeyValue{}
+		}
+		scope := otlpScopeSpans{Spans: groups[service]}
+		scope.Scope.Name = "github.com/lazygo/lazygo/trace"
+		rs.ScopeSpans = []otlpScopeSpans{scope}
+		req.ResourceSpans = append(req.ResourceSpans, rs)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.conf.Timeout)
+	defer cancel()
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for k, v := range e.conf.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Content-Type", "application/json")
+	resp, err := e.conf.Client.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp export failed: %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *otlpExporter) Shutdown(context.Context) error {
+	return nil
+}
+
+func otlpAttributes(attrs map[string]any) []otlpKeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	list := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, otlpKeyValue{Key: k, Value: otlpValue(attrs[k])})
+	}
+	return list
+}
+
+func otlpValue(v any) otlpAnyValue {
+	var val otlpAnyValue
+	switch x := v.(type) {
+	case string:
+		val.StringValue = &x
+	case bool:
+		val.BoolValue = &x
+	case int:
+		val.IntValue = ptr(strconv.FormatInt(int64(x), 10))
+	case int64:
+		val.IntValue = ptr(strconv.FormatInt(x, 10))
+	case int32:
+		val.IntValue = ptr(strconv.FormatInt(int64(x), 10))
+	case uint:
+		val.IntValue = ptr(strconv.FormatUint(uint64(x), 10))
+	case uint32:
+		val.IntValue = ptr(strconv.FormatUint(uint64(x), 10))
+	case uint64:
+		if x > math.MaxInt64 {
+			val.StringValue = ptr(strconv.FormatUint(x, 10))
+		} else {
+			val.IntValue = ptr(strconv.FormatUint(x, 10))
+		}
+	case float64:
+		val.DoubleValue = &x
+	case float32:
+		f := float64(x)
+		val.DoubleValue = &f
+	default:
+		val.StringValue = ptr(fmt.Sprint(v))
+	}
+	return val
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
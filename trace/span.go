@@ -0,0 +1,336 @@
+package trace
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultBatchSize     = 512
+	defaultQueueSize     = 2048
+	defaultFlushInterval = 5 * time.Second
+)
+
+// SpanKind span类型，取值与 OpenTelemetry 一致
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2
+	KindClient   SpanKind = 3
+)
+
+// StatusCode span状态，取值与 OpenTelemetry 一致
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+type Config struct {
+	// ServiceName 服务名称，导出时作为 service.name
+	ServiceName string
+	// Exporter 导出结束的span，为nil时只传递链路信息不记录span
+	Exporter Exporter
+	// SampleRatio 新链路的采样比例，取值0~1，小于等于0时为1；有上游时使用上游的采样标记
+	SampleRatio float64
+	// BatchSize 单次导出的最大span数，默认512
+	BatchSize int
+	// QueueSize 等待导出的最大span数，超过时丢弃，默认2048
+	QueueSize int
+	// FlushInterval 导出间隔，默认5秒
+	FlushInterval time.Duration
+}
+
+// SpanData 结束的span，交给 Exporter 导出
+type SpanData struct {
+	ServiceName   string
+	Name          string
+	Kind          SpanKind
+	TraceID       TraceID
+	SpanID        SpanID
+	ParentSpanID  SpanID
+	TraceState    string
+	Start         time.Time
+	End           time.Time
+	Attributes    map[string]any
+	Status        StatusCode
+	StatusMessage string
+}
+
+// Exporter 导出span
+type Exporter interface {
+	Export(ctx context.Context, spans []*SpanData) error
+	Shutdown(ctx context.Context) error
+}
+
+type tracer struct {
+	conf   Config
+	queue  chan *SpanData
+	flush  chan chan struct{}
+	done   chan struct{}
+	closed atomic.Bool
+}
+
+var current atomic.Pointer[tracer]
+
+// Init 初始化链路追踪，重复调用时先关闭之前的导出器
+func Init(conf Config) {
+	if conf.SampleRatio <= 0 {
+		conf.SampleRatio = 1
+	}
+	if conf.BatchSize <= 0 {
+		conf.BatchSize = defaultBatchSize
+	}
+	if conf.QueueSize <= 0 {
+		conf.QueueSize = defaultQueueSize
+	}
+	if conf.FlushInterval <= 0 {
+		conf.FlushInterval = defaultFlushInterval
+	}
+	t := &tracer{
+		conf:  conf,
+		queue: make(chan *SpanData, conf.QueueSize),
+		flush: make(chan chan struct{}),
+		done:  make(chan struct{}),
+	}
+	if conf.Exporter != nil {
+		go t.run()
+	} else {
+		close(t.done)
+	}
+	if old := current.Swap(t); old != nil {
+		_ = old.shutdown(context.Background())
+	}
+}
+
+// Flush 立即导出已结束的span
+func Flush(ctx context.Context) {
+	if t := current.Load(); t != nil && t.conf.Exporter != nil && !t.closed.Load() {
+		ch := make(chan struct{})
+		select {
+		case t.flush <- ch:
+		case <-t.done:
+			return
+		case <-ctx.Done():
+			return
+		}
+		select {
+		case <-ch:
+		case <-ctx.Done():
+		}
+	}
+}
+
+// Shutdown 导出剩余的span并关闭导出器
+func Shutdown(ctx context.Context) error {
+	t := current.Swap(nil)
+	if t == nil {
+		return nil
+	}
+	return t.shutdown(ctx)
+}
+
+func (t *tracer) shutdown(ctx context.Context) error {
+	if !t.closed.CompareAndSwap(false, true) || t.conf.Exporter == nil {
+		return nil
+	}
+	close(t.queue)
+	select {
+	case <-t.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	return t.conf.Exporter.Shutdown(ctx)
+}
+
+// run 按批次导出span，达到 BatchSize 或 FlushInterval 时导出
+func (t *tracer) run() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.conf.FlushInterval)
+	defer ticker.Stop()
+	batch := make([]*SpanData, 0, t.conf.BatchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		_ = t.conf.Exporter.Export(context.Background(), batch)
+		batch = make([]*SpanData, 0, t.conf.BatchSize)
+	}
+	for {
+		select {
+		case data, ok := <-t.queue:
+			if !ok {
+				export()
+				return
+			}
+			batch = append(batch, data)
+			if len(batch) >= t.conf.BatchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case ch := <-t.flush:
+			for n := len(t.queue); n > 0; n-- {
+				batch = append(batch, <-t.queue)
+			}
+			export()
+			close(ch)
+		}
+	}
+}
+
+func (t *tracer) enqueue(data *SpanData) {
+	if t.closed.Load() {
+		return
+	}
+	defer func() {
+		// 并发关闭时队列可能已关闭
+		_ = recover()
+	}()
+	select {
+	case t.queue <- data:
+	default:
+	}
+}
+
+// Span 一次操作的耗时和属性，所有方法都可以在nil上调用
+type Span struct {
+	mu     sync.Mutex
+	t      *tracer
+	name   string
+	kind   SpanKind
+	sc     SpanContext
+	parent SpanID
+	start  time.Time
+	attrs  map[string]any
+	status StatusCode
+	msg    string
+	ended  bool
+}
+
+// Start 创建span，ctx中有span或上游span信息时作为其子span
+// 返回的ctx包含新的span，用于传递给下游
+func Start(ctx context.Context, kind SpanKind, name string) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	span := &Span{
+		name:  name,
+		kind:  kind,
+		start: time.Now(),
+	}
+	t := current.Load()
+	if parent.IsValid() {
+		span.sc = SpanContext{TraceID: parent.TraceID, Flags: parent.Flags, TraceState: parent.TraceState}
+		span.parent = parent.SpanID
+	} else {
+		span.sc.TraceID = newTraceID()
+		if t != nil && sampleRatio(span.sc.TraceID, t.conf.SampleRatio) {
+			span.sc.Flags = FlagSampled
+		}
+	}
+	span.sc.SpanID = newSpanID()
+	if t != nil && t.conf.Exporter != nil && span.sc.IsSampled() {
+		span.t = t
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// StartChild 与 Start 相同，但ctx中没有span或上游span信息时不创建span，返回nil
+// 用于数据库、缓存等依赖调用，避免没有链路的调用产生独立的链路
+func StartChild(ctx context.Context, kind SpanKind, name string) (context.Context, *Span) {
+	if !SpanContextFromContext(ctx).IsValid() {
+		return ctx, nil
+	}
+	return Start(ctx, kind, name)
+}
+
+// SpanContext span的链路信息
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+// IsRecording span是否会被导出
+func (s *Span) IsRecording() bool {
+	return s != nil && s.t != nil
+}
+
+// SetName 修改span名称，如路由匹配后使用路由模式命名
+func (s *Span) SetName(name string) *Span {
+	if !s.IsRecording() {
+		return s
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+	return s
+}
+
+// SetAttr 设置属性，value 支持 string、bool、整数和浮点数，其他类型导出为字符串
+func (s *Span) SetAttr(key string, value any) *Span {
+	if !s.IsRecording() {
+		return s
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.attrs == nil {
+		s.attrs = make(map[string]any)
+	}
+	s.attrs[key] = value
+	return s
+}
+
+// SetStatus 设置状态
+func (s *Span) SetStatus(code StatusCode, msg string) *Span {
+	if !s.IsRecording() {
+		return s
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.msg = code, msg
+	return s
+}
+
+// SetError err不为nil时将状态设置为错误
+func (s *Span) SetError(err error) *Span {
+	if err == nil {
+		return s
+	}
+	return s.SetStatus(StatusError, err.Error())
+}
+
+// End 结束span并交给导出器，重复调用无效
+func (s *Span) End() {
+	if !s.IsRecording() {
+		return
+	}
+	end := time.Now()
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	data := &SpanData{
+		ServiceName:   s.t.conf.ServiceName,
+		Name:          s.name,
+		Kind:          s.kind,
+		TraceID:       s.sc.TraceID,
+		SpanID:        s.sc.SpanID,
+		ParentSpanID:  s.parent,
+		TraceState:    s.sc.TraceState,
+		Start:         s.start,
+		End:           end,
+		Attributes:    s.attrs,
+		Status:        s.status,
+		StatusMessage: s.msg,
+	}
+	s.mu.Unlock()
+	s.t.enqueue(data)
+}
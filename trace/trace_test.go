@@ -0,0 +1,123 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTraceparent(t *testing.T) {
+	const tp = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	sc, err := ParseTraceparent(tp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || !sc.IsSampled() || !sc.Remote {
+		t.Fatalf("unexpected span context %+v", sc)
+	}
+	if sc.Traceparent() != tp {
+		t.Fatalf("traceparent %s", sc.Traceparent())
+	}
+	for _, invalid := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, err := ParseTraceparent(invalid); err == nil {
+			t.Errorf("%q should be invalid", invalid)
+		}
+	}
+	// 未知版本可以带有额外字段
+	if _, err := ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); err != nil {
+		t.Error(err)
+	}
+
+	header := http.Header{}
+	header.Set(HeaderTraceparent, tp)
+	header.Set(HeaderTracestate, "vendor=value")
+	ctx, span := Start(Extract(context.Background(), header), KindServer, "GET /")
+	if span.SpanContext().TraceID != sc.TraceID || span.SpanContext().SpanID == sc.SpanID {
+		t.Fatal("span should continue the remote trace")
+	}
+	out := http.Header{}
+	Inject(ctx, out)
+	if out.Get(HeaderTracestate) != "vendor=value" || out.Get(HeaderTraceparent) != span.SpanContext().Traceparent() {
+		t.Fatalf("unexpected injected header %v", out)
+	}
+
+	// 没有上游时 StartChild 不创建span
+	if _, span := StartChild(context.Background(), KindClient, "SQL select"); span != nil {
+		t.Fatal("StartChild without parent should return nil")
+	}
+}
+
+func TestOTLPExporter(t *testing.T) {
+	received := make(chan otlpRequest, 1)
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("X-Token") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req otlpRequest
+		body, _ := io.ReadAll(r.Body)
+		if err := json.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		received <- req
+	}))
+	defer collector.Close()
+
+	Init(Config{
+		ServiceName: "test",
+		Exporter: NewOTLPExporter(OTLPConfig{
+			Endpoint: collector.URL,
+			Header:   http.Header{"X-Token": {"secret"}},
+		}),
+		FlushInterval: time.Hour,
+	})
+	defer Shutdown(context.Background())
+
+	ctx, root := Start(context.Background(), KindServer, "GET /users/:id")
+	root.SetAttr("http.response.status_code", 200)
+	_, child := StartChild(ctx, KindClient, "SQL select")
+	child.SetAttr("db.statement", "select 1").SetStatus(StatusError, "timeout")
+	child.End()
+	root.End()
+	Flush(context.Background())
+
+	var req otlpRequest
+	select {
+	case req = <-received:
+	case <-time.After(time.Second):
+		t.Fatal("collector did not receive spans")
+	}
+	if len(req.ResourceSpans) != 1 {
+		t.Fatalf("resource spans %d", len(req.ResourceSpans))
+	}
+	rs := req.ResourceSpans[0]
+	if v := rs.Resource.Attributes[0]; v.Key != "service.name" || *v.Value.StringValue != "test" {
+		t.Fatalf("unexpected resource %+v", rs.Resource)
+	}
+	spans := rs.ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("spans %d", len(spans))
+	}
+	sql, server := spans[0], spans[1]
+	if sql.TraceID != root.SpanContext().TraceID.String() || sql.ParentSpanID != server.SpanID || server.ParentSpanID != "" {
+		t.Fatalf("unexpected parent: %+v %+v", sql, server)
+	}
+	if sql.Kind != KindClient || sql.Status.Code != StatusError || sql.Status.Message != "timeout" {
+		t.Fatalf("unexpected sql span %+v", sql)
+	}
+	if a := server.Attributes[0]; a.Key != "http.response.status_code" || *a.Value.IntValue != "200" {
+		t.Fatalf("unexpected attributes %+v", server.Attributes)
+	}
+}